@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jcschmidt31/pdscan/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewCanaryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "canary",
+		Short: "Plant and check canary records",
+	}
+	cmd.AddCommand(NewCanaryPlantCmd())
+	cmd.AddCommand(NewCanaryCheckCmd())
+	return cmd
+}
+
+func NewCanaryPlantCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "plant [connection-uri]",
+		Short:        "Plant a canary record",
+		Long:         "Insert a canary record with a unique value into a SQL table, or write a canary file to a directory",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			table, err := cmd.Flags().GetString("table")
+			if err != nil {
+				return err
+			}
+
+			columns, err := cmd.Flags().GetStringArray("column")
+			if err != nil {
+				return err
+			}
+
+			canariesFile, err := cmd.Flags().GetString("canaries")
+			if err != nil {
+				return err
+			}
+
+			return internal.PlantCanary(args[0], table, columns, canariesFile, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("table", "", "Table to insert the canary record into")
+	cmd.Flags().StringArray("column", nil, "Column to set to the canary value, repeat for multiple columns")
+	cmd.Flags().String("canaries", "canaries.json", "File to store planted canaries")
+	return cmd
+}
+
+func NewCanaryCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "check [connection-uri]",
+		Short:        "Check where canaries have propagated",
+		Long:         "Scan for planted canaries and report where each was found",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, err := cmd.Flags().GetInt("sample-size")
+			if err != nil {
+				return err
+			}
+			if limit < 1 {
+				return fmt.Errorf("sample-size must be positive")
+			}
+
+			canariesFile, err := cmd.Flags().GetString("canaries")
+			if err != nil {
+				return err
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			return internal.CheckCanaries(args[0], canariesFile, limit, format, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("canaries", "canaries.json", "File with planted canaries")
+	cmd.Flags().String("format", "text", "Output format: text or json")
+	return cmd
+}
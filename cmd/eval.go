@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jcschmidt31/pdscan/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewEvalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "eval [connection-uri]",
+		Short:        "Measure precision and recall against labeled data",
+		Long:         "Scan labeled data, like from gen-corpus, and report precision, recall, and F1 for each rule",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			truthFile, err := cmd.Flags().GetString("truth")
+			if err != nil {
+				return err
+			}
+			if truthFile == "" {
+				return fmt.Errorf("--truth is required")
+			}
+
+			limit, err := cmd.Flags().GetInt("sample-size")
+			if err != nil {
+				return err
+			}
+			if limit < 1 {
+				return fmt.Errorf("sample-size must be positive")
+			}
+
+			showAll, err := cmd.Flags().GetBool("show-all")
+			if err != nil {
+				return err
+			}
+
+			only, err := cmd.Flags().GetString("only")
+			if err != nil {
+				return err
+			}
+
+			except, err := cmd.Flags().GetString("except")
+			if err != nil {
+				return err
+			}
+
+			rulesFile, err := cmd.Flags().GetString("rules")
+			if err != nil {
+				return err
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			return internal.Eval(args[0], truthFile, limit, showAll, only, except, rulesFile, format, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("truth", "", "Labels file, like labels.json from gen-corpus")
+	cmd.Flags().String("format", "text", "Output format: text or json")
+	return cmd
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/jcschmidt31/pdscan/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewGenCorpusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "gen-corpus [directory]",
+		Short:        "Generate synthetic data with labeled matches",
+		Long:         "Generate synthetic data with planted matches and a labels.json file, so precision and recall of rules can be measured",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rows, err := cmd.Flags().GetInt("rows")
+			if err != nil {
+				return err
+			}
+
+			formats, err := cmd.Flags().GetString("formats")
+			if err != nil {
+				return err
+			}
+
+			seed, err := cmd.Flags().GetInt64("seed")
+			if err != nil {
+				return err
+			}
+
+			matchRate, err := cmd.Flags().GetFloat64("match-rate")
+			if err != nil {
+				return err
+			}
+
+			return internal.GenerateCorpus(args[0], rows, formats, seed, matchRate)
+		},
+	}
+	cmd.Flags().Int("rows", 10000, "Number of rows in each file")
+	cmd.Flags().String("formats", "csv,jsonl,sql", "File formats to generate")
+	cmd.Flags().Int64("seed", 1, "Random seed, so the same data is generated each time")
+	cmd.Flags().Float64("match-rate", 0.1, "Fraction of values with planted data")
+	return cmd
+}
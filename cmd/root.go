@@ -15,6 +15,8 @@ func NewRootCmd() *cobra.Command {
 		Short:        "Scan your data stores for unencrypted personal data (PII)",
 		Long:         "Scan your data stores for unencrypted personal data (PII)",
 		SilenceUsage: true,
+		// connection URIs are not subcommands
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			showData, err := cmd.Flags().GetBool("show-data")
 			if err != nil {
@@ -72,6 +74,181 @@ func NewRootCmd() *cobra.Command {
 				return err
 			}
 
+			maxFileSize, err := cmd.Flags().GetString("max-file-size")
+			if err != nil {
+				return err
+			}
+
+			query, err := cmd.Flags().GetString("query")
+			if err != nil {
+				return err
+			}
+
+			scopeFile, err := cmd.Flags().GetString("scope")
+			if err != nil {
+				return err
+			}
+
+			templateFile, err := cmd.Flags().GetString("template")
+			if err != nil {
+				return err
+			}
+
+			detectors, err := cmd.Flags().GetStringArray("detector")
+			if err != nil {
+				return err
+			}
+
+			secretMinLength, err := cmd.Flags().GetInt("secret-min-length")
+			if err != nil {
+				return err
+			}
+
+			secretEntropy, err := cmd.Flags().GetFloat64("secret-entropy")
+			if err != nil {
+				return err
+			}
+
+			since, err := cmd.Flags().GetString("since")
+			if err != nil {
+				return err
+			}
+
+			stateFile, err := cmd.Flags().GetString("state")
+			if err != nil {
+				return err
+			}
+
+			notifyUrl, err := cmd.Flags().GetString("notify-url")
+			if err != nil {
+				return err
+			}
+
+			notifySlack, err := cmd.Flags().GetString("notify-slack")
+			if err != nil {
+				return err
+			}
+
+			notifyThreshold, err := cmd.Flags().GetInt("notify-threshold")
+			if err != nil {
+				return err
+			}
+
+			ignoreFile, err := cmd.Flags().GetString("ignore")
+			if err != nil {
+				return err
+			}
+
+			statsFile, err := cmd.Flags().GetString("stats")
+			if err != nil {
+				return err
+			}
+
+			timeBudget, err := cmd.Flags().GetString("time-budget")
+			if err != nil {
+				return err
+			}
+
+			labels, err := cmd.Flags().GetStringArray("label")
+			if err != nil {
+				return err
+			}
+
+			nice, err := cmd.Flags().GetBool("nice")
+			if err != nil {
+				return err
+			}
+
+			ioLimit, err := cmd.Flags().GetString("io-limit")
+			if err != nil {
+				return err
+			}
+
+			redact, err := cmd.Flags().GetString("redact")
+			if err != nil {
+				return err
+			}
+
+			maxValues, err := cmd.Flags().GetInt("max-values")
+			if err != nil {
+				return err
+			}
+
+			phoneRegions, err := cmd.Flags().GetString("phone-regions")
+			if err != nil {
+				return err
+			}
+
+			timeoutPerObject, err := cmd.Flags().GetString("timeout-per-object")
+			if err != nil {
+				return err
+			}
+
+			newestFirst, err := cmd.Flags().GetBool("newest-first")
+			if err != nil {
+				return err
+			}
+
+			modifiedSince, err := cmd.Flags().GetString("modified-since")
+			if err != nil {
+				return err
+			}
+
+			samplePrefixes, err := cmd.Flags().GetStringArray("sample-prefix")
+			if err != nil {
+				return err
+			}
+
+			assumeRoles, err := cmd.Flags().GetStringArray("assume-role")
+			if err != nil {
+				return err
+			}
+
+			accounts, err := cmd.Flags().GetString("accounts")
+			if err != nil {
+				return err
+			}
+
+			impersonateServiceAccount, err := cmd.Flags().GetString("impersonate-service-account")
+			if err != nil {
+				return err
+			}
+
+			cardData, err := cmd.Flags().GetBool("card-data")
+			if err != nil {
+				return err
+			}
+
+			rulesFile, err := cmd.Flags().GetString("rules")
+			if err != nil {
+				return err
+			}
+
+			columnBudget, err := cmd.Flags().GetString("column-budget")
+			if err != nil {
+				return err
+			}
+
+			forensic, err := cmd.Flags().GetBool("forensic")
+			if err != nil {
+				return err
+			}
+
+			lineage, err := cmd.Flags().GetBool("lineage")
+			if err != nil {
+				return err
+			}
+
+			healthcheckFile, err := cmd.Flags().GetString("healthcheck-file")
+			if err != nil {
+				return err
+			}
+
+			obfuscated, err := cmd.Flags().GetBool("obfuscated")
+			if err != nil {
+				return err
+			}
+
 			if len(args) == 0 {
 				cmd.Help()
 				os.Exit(1)
@@ -82,10 +259,60 @@ func NewRootCmd() *cobra.Command {
 			// 	return fmt.Errorf("Too many arguments")
 			// }
 
-			return internal.Main(args[0], showData, showAll, limit, processes, only, except, minCount, pattern, debug, format)
+			return internal.Main(internal.MainOpts{
+				UrlStr:                    args[0],
+				ShowData:                  showData,
+				ShowAll:                   showAll,
+				Limit:                     limit,
+				Processes:                 processes,
+				Only:                      only,
+				Except:                    except,
+				MinCount:                  minCount,
+				Pattern:                   pattern,
+				Debug:                     debug,
+				Format:                    format,
+				MaxFileSize:               maxFileSize,
+				Query:                     query,
+				ScopeFile:                 scopeFile,
+				TemplateFile:              templateFile,
+				Detectors:                 detectors,
+				SecretMinLength:           secretMinLength,
+				SecretEntropy:             secretEntropy,
+				Since:                     since,
+				StateFile:                 stateFile,
+				NotifyUrl:                 notifyUrl,
+				NotifySlack:               notifySlack,
+				NotifyThreshold:           notifyThreshold,
+				IgnoreFile:                ignoreFile,
+				StatsFile:                 statsFile,
+				TimeBudget:                timeBudget,
+				Labels:                    labels,
+				Nice:                      nice,
+				IoLimit:                   ioLimit,
+				Redact:                    redact,
+				MaxValues:                 maxValues,
+				PhoneRegions:              phoneRegions,
+				TimeoutPerObject:          timeoutPerObject,
+				NewestFirst:               newestFirst,
+				ModifiedSince:             modifiedSince,
+				SamplePrefixes:            samplePrefixes,
+				AssumeRoles:               assumeRoles,
+				Accounts:                  accounts,
+				ImpersonateServiceAccount: impersonateServiceAccount,
+				CardData:                  cardData,
+				RulesFile:                 rulesFile,
+				ColumnBudget:              columnBudget,
+				Forensic:                  forensic,
+				Lineage:                   lineage,
+				HealthcheckFile:           healthcheckFile,
+				Obfuscated:                obfuscated,
+			})
 		},
 	}
 	cmd.PersistentFlags().Bool("show-data", false, "Show data")
+	cmd.PersistentFlags().String("redact", "", "Show redacted data: partial, hash, or full")
+	cmd.PersistentFlags().Int("max-values", 50, "Maximum unique values to show for each match")
+	cmd.PersistentFlags().String("phone-regions", "", "Only report phone numbers that are valid in these regions, like US,GB")
 	cmd.PersistentFlags().Bool("show-all", false, "Show all matches")
 	cmd.PersistentFlags().Int("sample-size", 10000, "Sample size")
 	cmd.PersistentFlags().Int("processes", 1, "Processes")
@@ -93,9 +320,49 @@ func NewRootCmd() *cobra.Command {
 	cmd.PersistentFlags().String("except", "", "Except certain rules")
 	cmd.PersistentFlags().Int("min-count", 1, "Minimum rows/documents/lines for a match (experimental)")
 	cmd.PersistentFlags().String("pattern", "", "Custom pattern (experimental)")
+	cmd.PersistentFlags().Bool("lineage", false, "Report values shared between tables, columns, and files")
+	cmd.PersistentFlags().Bool("card-data", false, "Also detect card track data and CVVs")
+	cmd.PersistentFlags().Bool("obfuscated", false, "Also detect obfuscated emails and phone numbers, like john at example dot com")
+	cmd.PersistentFlags().Int("secret-min-length", 20, "Minimum length for possible secrets")
+	cmd.PersistentFlags().Float64("secret-entropy", 4.5, "Minimum Shannon entropy for possible secrets (bits per character)")
 	cmd.PersistentFlags().Bool("debug", false, "Debug")
 	cmd.PersistentFlags().MarkHidden("debug")
 	cmd.PersistentFlags().String("format", "text", "Output format (experimental)")
+	cmd.PersistentFlags().String("template", "", "Template file for template format")
+	cmd.PersistentFlags().StringArray("label", nil, "Label to include in structured output, like env=prod")
+	cmd.PersistentFlags().String("max-file-size", "", "Skip files larger than this size, like 500MB")
+	cmd.PersistentFlags().String("column-budget", "", "Truncate values of columns with more than this size sampled, like 10MB")
+	cmd.PersistentFlags().String("time-budget", "", "Spread scan time across tables and files to finish within this duration, like 2h")
+	cmd.PersistentFlags().String("timeout-per-object", "", "Skip tables and files that take longer than this duration, like 5m")
+	cmd.PersistentFlags().Bool("forensic", false, "Also scan deleted data in SQLite files and disk images (experimental)")
+	cmd.PersistentFlags().Bool("newest-first", false, "Scan the most recently modified files and objects first")
+	cmd.PersistentFlags().StringArray("assume-role", nil, "AWS role to assume for S3, repeat to chain roles")
+	cmd.PersistentFlags().String("accounts", "", "AWS accounts to scan, like 111111111111,222222222222, or organization for all accounts")
+	cmd.PersistentFlags().String("impersonate-service-account", "", "GCP service account to impersonate for BigQuery, Cloud Storage, and Firestore")
+	cmd.PersistentFlags().StringArray("sample-prefix", nil, "Percent of files or objects to scan under a prefix, like logs/=1%")
+	cmd.PersistentFlags().String("modified-since", "", "Only scan files and objects modified within this time, like 30d, or after a time, like 2024-01-01")
+	cmd.PersistentFlags().Bool("nice", false, "Lower CPU and disk priority to reduce impact on other processes")
+	cmd.PersistentFlags().String("io-limit", "", "Limit disk reads for local files to this size per second, like 10MB")
+	cmd.PersistentFlags().String("query", "", "Scan the results of a SQL query")
+	cmd.PersistentFlags().String("since", "", "Only sample rows updated after this time")
+	cmd.PersistentFlags().String("state", "", "File to store watermarks so later scans only sample new rows")
+	cmd.PersistentFlags().String("notify-url", "", "Post a JSON summary of matches to this URL")
+	cmd.PersistentFlags().String("notify-slack", "", "Post a summary of matches to this Slack webhook URL")
+	cmd.PersistentFlags().Int("notify-threshold", 1, "Minimum matches to send a notification")
+	cmd.PersistentFlags().String("healthcheck-file", "", "Write the result of the scan to this file for pdscan status")
+	cmd.PersistentFlags().String("stats", "", "Write scan metrics to a Prometheus textfile, or JSON with a .json extension")
+	cmd.PersistentFlags().StringArray("detector", nil, "Command for a custom detector (experimental)")
+	cmd.PersistentFlags().String("scope", "", "Scope file with tables to include and exclude")
+	cmd.PersistentFlags().String("ignore", "", "File with known-safe values and patterns to ignore")
+	cmd.PersistentFlags().String("rules", "", "File with custom rules")
+	cmd.AddCommand(NewScopeCmd())
+	cmd.AddCommand(NewServeCmd())
+	cmd.AddCommand(NewGenCorpusCmd())
+	cmd.AddCommand(NewEvalCmd())
+	cmd.AddCommand(NewCanaryCmd())
+	cmd.AddCommand(NewStatusCmd())
+	cmd.AddCommand(NewServiceCmd())
+	cmd.CompletionOptions.DisableDefaultCmd = true
 	return cmd
 }
 
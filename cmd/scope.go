@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jcschmidt31/pdscan/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewScopeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scope",
+		Short: "Manage scope files",
+	}
+	cmd.AddCommand(NewScopeGenerateCmd())
+	return cmd
+}
+
+func NewScopeGenerateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "generate [connection-uri]",
+		Short:        "Generate a scope file from column names",
+		Long:         "Generate a scope file from column names, grouping tables by likely sensitivity",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, err := cmd.Flags().GetInt("sample-size")
+			if err != nil {
+				return err
+			}
+			if limit < 1 {
+				return fmt.Errorf("sample-size must be positive")
+			}
+
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				return internal.GenerateScope(args[0], limit, cmd.OutOrStdout())
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			return internal.GenerateScope(args[0], limit, f)
+		},
+	}
+	cmd.Flags().String("output", "", "Write to a file instead of stdout")
+	return cmd
+}
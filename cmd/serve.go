@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/jcschmidt31/pdscan/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "serve",
+		Short:        "Run scans on demand with an HTTP API",
+		Long:         "Run scans on demand with an HTTP API, so teams can submit data store URLs or upload content and poll for results",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listen, err := cmd.Flags().GetString("listen")
+			if err != nil {
+				return err
+			}
+
+			concurrency, err := cmd.Flags().GetInt("concurrency")
+			if err != nil {
+				return err
+			}
+
+			queueSize, err := cmd.Flags().GetInt("queue-size")
+			if err != nil {
+				return err
+			}
+
+			dashboard, err := cmd.Flags().GetBool("dashboard")
+			if err != nil {
+				return err
+			}
+
+			dashboardSamples, err := cmd.Flags().GetString("dashboard-samples")
+			if err != nil {
+				return err
+			}
+
+			return internal.Serve(listen, concurrency, queueSize, dashboard, dashboardSamples)
+		},
+	}
+	cmd.Flags().String("listen", "127.0.0.1:8080", "Address to listen on")
+	cmd.Flags().Int("concurrency", 2, "Number of scans to run at once")
+	cmd.Flags().Int("queue-size", 100, "Number of scans that can wait to run")
+	cmd.Flags().Bool("dashboard", false, "Serve a web dashboard at /")
+	cmd.Flags().String("dashboard-samples", "", "Keep samples of matched data for the dashboard, redacted with partial, hash, or full")
+	return cmd
+}
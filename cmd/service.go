@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/jcschmidt31/pdscan/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewServiceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Manage system services",
+	}
+	cmd.AddCommand(NewServiceInstallCmd())
+	return cmd
+}
+
+func NewServiceInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "install -- [command]",
+		Short:        "Install a systemd unit or Windows service",
+		Long:         "Install a systemd unit or Windows service that runs pdscan serve, or a systemd timer that runs a scan on a schedule",
+		Example:      "  pdscan service install -- serve --listen 0.0.0.0:8080\n  pdscan service install --schedule daily -- postgres://host/dbname --healthcheck-file /var/lib/pdscan/last-scan.json",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, err := cmd.Flags().GetString("name")
+			if err != nil {
+				return err
+			}
+
+			schedule, err := cmd.Flags().GetString("schedule")
+			if err != nil {
+				return err
+			}
+
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+
+			return internal.ServiceInstall(name, schedule, args, dryRun, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("name", "pdscan", "Service name")
+	cmd.Flags().String("schedule", "", "Run a scan on this systemd calendar schedule, like daily or Mon *-*-* 02:00")
+	cmd.Flags().Bool("dry-run", false, "Print the service instead of installing it")
+	return cmd
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jcschmidt31/pdscan/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "status",
+		Short:        "Check the last scan",
+		Long:         "Check the last scan recorded with --healthcheck-file, exiting with an error if it failed or is too old",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			healthcheckFile, err := cmd.Flags().GetString("healthcheck-file")
+			if err != nil {
+				return err
+			}
+			if healthcheckFile == "" {
+				return fmt.Errorf("--healthcheck-file is required")
+			}
+
+			maxAge, err := cmd.Flags().GetString("max-age")
+			if err != nil {
+				return err
+			}
+
+			return internal.Status(healthcheckFile, maxAge, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("max-age", "", "Fail if the last scan finished longer ago than this duration, like 25h")
+	return cmd
+}
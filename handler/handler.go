@@ -0,0 +1,85 @@
+// Package handler lets programs that embed pdscan scan in-house file formats.
+//
+// Register handlers before running the command:
+//
+//	func main() {
+//		handler.Register(handler.Handler{
+//			ContentType: "application/x-acme",
+//			Detect:      func(head []byte) bool { return bytes.HasPrefix(head, []byte("ACME")) },
+//			Process:     processAcme,
+//		})
+//		cmd.Execute()
+//	}
+//
+// Handlers are keyed by content type, so registering a built-in type, like
+// application/x-ndjson, replaces how pdscan scans it.
+package handler
+
+import (
+	"io"
+)
+
+// Scanner receives values from a handler
+type Scanner interface {
+	// Line scans a line of text
+	Line(text string)
+	// Record scans a structured record, like a decoded JSON object, by field
+	Record(record map[string]interface{})
+}
+
+// Handler scans a file format, detected from the start of the file
+type Handler struct {
+	// like application/x-acme
+	ContentType string
+	// head is up to the first 64 KB of the file
+	// optional for built-in content types, which pdscan already detects
+	Detect  func(head []byte) bool
+	Process func(reader io.Reader, scanner Scanner) error
+}
+
+var (
+	handlers = make(map[string]Handler)
+	// content types in the order registered, since detection is checked in order
+	contentTypes []string
+)
+
+// Register adds a handler for a content type, replacing any handler for the same type
+// handlers registered first are detected first, before the built-in formats
+func Register(h Handler) {
+	if h.ContentType == "" {
+		panic("handler: content type is required")
+	}
+	if _, ok := handlers[h.ContentType]; !ok {
+		contentTypes = append(contentTypes, h.ContentType)
+	}
+	handlers[h.ContentType] = h
+}
+
+// Unregister removes the handler for a content type
+func Unregister(contentType string) {
+	if _, ok := handlers[contentType]; !ok {
+		return
+	}
+	delete(handlers, contentType)
+	for i, t := range contentTypes {
+		if t == contentType {
+			contentTypes = append(contentTypes[:i], contentTypes[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup returns the handler for a content type
+func Lookup(contentType string) (Handler, bool) {
+	h, ok := handlers[contentType]
+	return h, ok
+}
+
+// Handlers returns the registered handlers in the order registered
+func Handlers() []Handler {
+	list := make([]Handler, len(contentTypes))
+	for i, t := range contentTypes {
+		list[i] = handlers[t]
+	}
+	return list
+}
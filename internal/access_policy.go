@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// accessPolicy describes data hidden from the current user by the database
+type accessPolicy struct {
+	// rows may be filtered by row-level security
+	RowSecurity bool
+	// columns without permission to read
+	DeniedColumns []string
+	// columns with masked values
+	MaskedColumns []string
+}
+
+// accessPolicyAdapter is implemented by adapters that can detect access policies
+// so coverage gaps are reported instead of looking like clean results
+type accessPolicyAdapter interface {
+	FetchAccessPolicy(ctx context.Context, table table) (*accessPolicy, error)
+	IsAccessDenied(err error) bool
+}
+
+// masked values are placeholders, like xxxx@xxxx.com, so only check names
+func (p *accessPolicy) removeMaskedValues(data *tableData) {
+	for i, col := range data.ColumnNames {
+		if stringInSlice(col, p.MaskedColumns) {
+			data.ColumnValues[i] = []string{}
+		}
+	}
+}
+
+func (p *accessPolicy) report(table table, c *coverage) {
+	if p.RowSecurity {
+		fmt.Fprintf(os.Stderr, "Partially scanned %s (some rows not assessable due to row-level security)\n", table.displayName())
+		c.skip(table.displayName(), skipPermissionDenied, "some rows not assessable due to row-level security")
+	}
+	for _, col := range p.MaskedColumns {
+		fmt.Fprintf(os.Stderr, "Skipped values of %s.%s (not assessable due to column masking)\n", table.displayName(), col)
+		c.skip(table.displayName()+"."+col, skipPermissionDenied, "values not assessable due to column masking")
+	}
+}
+
+func reportAccessDenied(table table, policy *accessPolicy, c *coverage) {
+	if policy != nil && len(policy.DeniedColumns) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %s (not assessable due to access policy on %s)\n", table.displayName(), strings.Join(policy.DeniedColumns, ", "))
+		for _, col := range policy.DeniedColumns {
+			c.skip(table.displayName()+"."+col, skipPermissionDenied, "not assessable due to access policy")
+		}
+		c.skip(table.displayName(), skipPermissionDenied, "table not scanned since some columns are not assessable")
+	} else {
+		fmt.Fprintf(os.Stderr, "Skipped %s (not assessable due to access policy)\n", table.displayName())
+		c.skip(table.displayName(), skipPermissionDenied, "not assessable due to access policy")
+	}
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// AmplitudeAdapter scans event and user properties with the Amplitude export API
+type AmplitudeAdapter struct {
+	endpoint  string
+	apiKey    string
+	secretKey string
+	events    *analyticsEvents
+}
+
+// exported properties of each event, besides event and user properties
+var amplitudeEventFields = []string{"user_id", "ip_address", "city", "region"}
+
+func (a *AmplitudeAdapter) TableName() string {
+	return "event type"
+}
+
+func (a *AmplitudeAdapter) RowName() string {
+	return "event"
+}
+
+func (a *AmplitudeAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *AmplitudeAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	a.apiKey = u.User.Username()
+	if a.apiKey == "" {
+		a.apiKey = os.Getenv("AMPLITUDE_API_KEY")
+	}
+	var ok bool
+	a.secretKey, ok = u.User.Password()
+	if !ok {
+		a.secretKey = os.Getenv("AMPLITUDE_SECRET_KEY")
+	}
+	if a.apiKey == "" || a.secretKey == "" {
+		return errors.New("no API key or secret key specified")
+	}
+
+	a.endpoint = "https://amplitude.com"
+	if u.Query().Get("region") == "eu" {
+		a.endpoint = "https://analytics.eu.amplitude.com"
+	}
+	return nil
+}
+
+func (a *AmplitudeAdapter) FetchTables() ([]table, error) {
+	err := a.fetchEvents()
+	if err != nil {
+		return nil, err
+	}
+	return a.events.tables(), nil
+}
+
+func (a AmplitudeAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	return a.events.tableData(table.Name, limit), nil
+}
+
+// exports are a zip file with a gzipped JSON lines file for each hour
+// events from the previous day are exported, since recent events can take hours to be available
+// https://amplitude.com/docs/apis/analytics/export
+func (a *AmplitudeAdapter) fetchEvents() error {
+	day := time.Now().UTC().AddDate(0, 0, -1).Format("20060102")
+	query := url.Values{}
+	query.Set("start", day+"T00")
+	query.Set("end", day+"T23")
+
+	req, err := http.NewRequest("GET", a.endpoint+"/api/2/export?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(a.apiKey, a.secretKey)
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	a.events = newAnalyticsEvents()
+
+	// no events
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("[%s] %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// zip files are read from the end
+	tmp, err := os.CreateTemp("", "pdscan*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	archive, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range archive.File {
+		if a.events.full() {
+			break
+		}
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		err := a.addFile(file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *AmplitudeAdapter) addFile(file *zip.File) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	return a.events.addLines(gzipReader, parseAmplitudeEvent)
+}
+
+func parseAmplitudeEvent(line []byte) (string, map[string]interface{}, bool) {
+	object, ok := parseJsonLine(line)
+	if !ok {
+		return "", nil, false
+	}
+	name, ok := object["event_type"].(string)
+	if !ok {
+		return "", nil, false
+	}
+
+	properties := make(map[string]interface{})
+	for _, field := range append([]string{"event_properties", "user_properties"}, amplitudeEventFields...) {
+		if v, ok := object[field]; ok && v != nil {
+			properties[field] = v
+		}
+	}
+	return name, properties, true
+}
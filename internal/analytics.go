@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// events downloaded from analytics exports, which can't be sampled by event
+const analyticsMaxEvents = 100000
+
+// analyticsEvents groups exported events by name, so properties are scanned like columns of a table
+type analyticsEvents struct {
+	properties map[string][]map[string]interface{}
+	count      int
+}
+
+func newAnalyticsEvents() *analyticsEvents {
+	return &analyticsEvents{properties: make(map[string][]map[string]interface{})}
+}
+
+func (e *analyticsEvents) full() bool {
+	return e.count >= analyticsMaxEvents
+}
+
+func (e *analyticsEvents) add(name string, properties map[string]interface{}) {
+	if e.full() {
+		return
+	}
+	e.properties[name] = append(e.properties[name], properties)
+	e.count++
+}
+
+// exports are JSON lines, and lines that aren't events are skipped
+func (e *analyticsEvents) addLines(reader io.Reader, parse func(line []byte) (string, map[string]interface{}, bool)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, chunkSize), chunkSize*16)
+	for !e.full() && scanner.Scan() {
+		name, properties, ok := parse(scanner.Bytes())
+		if ok {
+			e.add(name, properties)
+		}
+	}
+	return scanner.Err()
+}
+
+func (e *analyticsEvents) tables() []table {
+	tables := []table{}
+	for name := range e.properties {
+		tables = append(tables, table{Schema: "", Name: name})
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+func (e *analyticsEvents) tableData(name string, limit int) *tableData {
+	events := e.properties[name]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	keyMap := make(map[string]int)
+	columnValues := make([][]string, 0)
+	for _, properties := range events {
+		keyMap, columnValues = scanSource(properties, "", keyMap, columnValues)
+	}
+
+	columnNames := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: len(events)}
+}
+
+func parseJsonLine(line []byte) (map[string]interface{}, bool) {
+	var object map[string]interface{}
+	if json.Unmarshal(line, &object) != nil {
+		return nil, false
+	}
+	return object, true
+}
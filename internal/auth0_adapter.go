@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"errors"
+	"net/url"
+	"os"
+)
+
+// Auth0Adapter scans user and app metadata with the Auth0 Management API
+// root attributes, like email and phone_number, are expected to have personal data, so they are skipped
+type Auth0Adapter struct {
+	RestAdapter
+}
+
+func (a *Auth0Adapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *Auth0Adapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	token, ok := u.User.Password()
+	if !ok {
+		token = os.Getenv("AUTH0_TOKEN")
+	}
+	if token == "" {
+		return errors.New("no API token specified")
+	}
+
+	return a.init(newAuth0Config("https://"+u.Host, token))
+}
+
+func newAuth0Config(endpoint string, token string) *restConfig {
+	// pages start at 0 and have at most 100 users
+	start := 0
+	pagination := restPagination{Type: "page", SizeParam: "per_page", Size: 100, Start: &start}
+
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "bearer"
+	config.Auth.Token = token
+	config.Resources = []restResource{
+		{Name: "users", Path: "/api/v2/users", Fields: []string{"user_metadata", "app_metadata"}, Pagination: pagination},
+	}
+	return config
+}
@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// BigqueryAdapter scans BigQuery tables using the REST API
+type BigqueryAdapter struct {
+	endpoint string
+	project  string
+	dataset  string
+	client   *http.Client
+	// service accounts to impersonate, with the target last
+	impersonate []string
+}
+
+type bigqueryField struct {
+	Name   string          `json:"name"`
+	Type   string          `json:"type"`
+	Mode   string          `json:"mode"`
+	Fields []bigqueryField `json:"fields"`
+}
+
+func (a *BigqueryAdapter) TableName() string {
+	return "table"
+}
+
+func (a *BigqueryAdapter) RowName() string {
+	return "row"
+}
+
+func (a *BigqueryAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *BigqueryAdapter) Init(urlStr string) error {
+	client, err := gcpClient(context.Background(), a.impersonate)
+	if err != nil {
+		return err
+	}
+	return a.initEndpoint(urlStr, "https://bigquery.googleapis.com", client)
+}
+
+func (a *BigqueryAdapter) initEndpoint(urlStr string, endpoint string, client *http.Client) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	a.project = u.Host
+	if a.project == "" {
+		return errors.New("no project specified")
+	}
+	if len(u.Path) > 1 {
+		a.dataset = u.Path[1:]
+	}
+	a.endpoint = endpoint
+	a.client = client
+	return nil
+}
+
+func (a *BigqueryAdapter) setImpersonate(serviceAccounts []string) {
+	a.impersonate = serviceAccounts
+}
+
+func (a *BigqueryAdapter) projectUrl() string {
+	return a.endpoint + "/bigquery/v2/projects/" + url.PathEscape(a.project)
+}
+
+func (a *BigqueryAdapter) FetchTables() ([]table, error) {
+	tables := []table{}
+
+	var datasets []string
+	if a.dataset != "" {
+		datasets = []string{a.dataset}
+	} else {
+		var err error
+		datasets, err = a.fetchDatasets()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, dataset := range datasets {
+		pageToken := ""
+		for {
+			var result struct {
+				Tables []struct {
+					TableReference struct {
+						TableId string `json:"tableId"`
+					} `json:"tableReference"`
+					Type string `json:"type"`
+				} `json:"tables"`
+				NextPageToken string `json:"nextPageToken"`
+			}
+			_, err := gcpRequest(context.Background(), a.client, "GET", a.projectUrl()+"/datasets/"+url.PathEscape(dataset)+"/tables?"+bigqueryPage(pageToken, 0), nil, &result)
+			if err != nil {
+				return nil, err
+			}
+
+			// views and external tables have no stored rows to list
+			for _, t := range result.Tables {
+				if t.Type == "TABLE" {
+					tables = append(tables, table{Schema: dataset, Name: t.TableReference.TableId})
+				}
+			}
+
+			pageToken = result.NextPageToken
+			if pageToken == "" {
+				break
+			}
+		}
+	}
+
+	return tables, nil
+}
+
+func (a *BigqueryAdapter) fetchDatasets() ([]string, error) {
+	datasets := []string{}
+	pageToken := ""
+	for {
+		var result struct {
+			Datasets []struct {
+				DatasetReference struct {
+					DatasetId string `json:"datasetId"`
+				} `json:"datasetReference"`
+			} `json:"datasets"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		_, err := gcpRequest(context.Background(), a.client, "GET", a.projectUrl()+"/datasets?"+bigqueryPage(pageToken, 0), nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dataset := range result.Datasets {
+			datasets = append(datasets, dataset.DatasetReference.DatasetId)
+		}
+
+		pageToken = result.NextPageToken
+		if pageToken == "" {
+			return datasets, nil
+		}
+	}
+}
+
+// reads rows with tabledata.list instead of a query, so sampling has no query cost
+func (a *BigqueryAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	tableUrl := a.projectUrl() + "/datasets/" + url.PathEscape(table.Schema) + "/tables/" + url.PathEscape(table.Name)
+
+	var metadata struct {
+		Schema struct {
+			Fields []bigqueryField `json:"fields"`
+		} `json:"schema"`
+	}
+	_, err := gcpRequest(ctx, a.client, "GET", tableUrl, nil, &metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	keyMap := make(map[string]int)
+	columnValues := make([][]string, 0)
+
+	// TODO randomize
+	count := 0
+	pageToken := ""
+	for count < limit {
+		var result struct {
+			Rows      []map[string]interface{} `json:"rows"`
+			PageToken string                   `json:"pageToken"`
+		}
+		_, err := gcpRequest(ctx, a.client, "GET", tableUrl+"/data?"+bigqueryPage(pageToken, limit-count), nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range result.Rows {
+			keyMap, columnValues = scanSource(bigqueryRecord(metadata.Schema.Fields, row), "", keyMap, columnValues)
+		}
+		count += len(result.Rows)
+
+		pageToken = result.PageToken
+		if pageToken == "" || len(result.Rows) == 0 {
+			break
+		}
+	}
+
+	columnNames := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: count}, nil
+}
+
+func bigqueryPage(pageToken string, maxResults int) string {
+	params := url.Values{}
+	if pageToken != "" {
+		params.Set("pageToken", pageToken)
+	}
+	if maxResults > 0 {
+		params.Set("maxResults", strconv.Itoa(maxResults))
+	}
+	return params.Encode()
+}
+
+// rows are like {"f": [{"v": "value"}]}, with values in the order of the schema
+func bigqueryRecord(fields []bigqueryField, row map[string]interface{}) map[string]interface{} {
+	record := make(map[string]interface{})
+	cells, _ := row["f"].([]interface{})
+	for i, cell := range cells {
+		if i >= len(fields) {
+			break
+		}
+		if cellMap, ok := cell.(map[string]interface{}); ok {
+			record[fields[i].Name] = bigqueryValue(fields[i], cellMap["v"])
+		}
+	}
+	return record
+}
+
+// scalars are strings, including numbers, so numeric IDs like SSNs are scanned
+func bigqueryValue(field bigqueryField, value interface{}) interface{} {
+	if field.Mode == "REPEATED" {
+		items, _ := value.([]interface{})
+		element := field
+		element.Mode = ""
+		values := []interface{}{}
+		for _, item := range items {
+			if itemMap, ok := item.(map[string]interface{}); ok {
+				values = append(values, bigqueryValue(element, itemMap["v"]))
+			}
+		}
+		return values
+	}
+
+	if field.Type == "RECORD" || field.Type == "STRUCT" {
+		if valueMap, ok := value.(map[string]interface{}); ok {
+			return bigqueryRecord(field.Fields, valueMap)
+		}
+		return nil
+	}
+
+	return value
+}
@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// canaries are records with a unique value, planted so later scans show where data flows,
+// like to other databases, logs, and buckets
+type canaryState struct {
+	Canaries []canary `json:"canaries"`
+}
+
+type canary struct {
+	Id    string `json:"id"`
+	Value string `json:"value"`
+	// redacted connection URI
+	Source string `json:"source"`
+	// column or file, like users.email
+	Location  string    `json:"location"`
+	PlantedAt time.Time `json:"planted_at"`
+}
+
+type canaryResult struct {
+	canary
+	FoundIn []string `json:"found_in"`
+}
+
+// values are emails, so they pass validation and flow like real data
+func newCanaryValue(id string) string {
+	return "pdscan-canary-" + id + "@example.org"
+}
+
+// PlantCanary inserts a canary record into a SQL table, or writes a canary file to a local directory
+// the canary is saved to the canaries file for later checks
+func PlantCanary(urlStr string, tableName string, columns []string, canariesFile string, output io.Writer) error {
+	state, err := loadCanaries(canariesFile)
+	if err != nil {
+		return err
+	}
+
+	idBytes := make([]byte, 6)
+	_, err = rand.Read(idBytes)
+	if err != nil {
+		return err
+	}
+	id := hex.EncodeToString(idBytes)
+	value := newCanaryValue(id)
+
+	var location string
+	switch adapter := newAdapter(urlStr, "").(type) {
+	case *SqlAdapter:
+		if tableName == "" || len(columns) == 0 {
+			return fmt.Errorf("--table and --column are required for SQL databases")
+		}
+		err = adapter.Init(urlStr)
+		if err != nil {
+			return err
+		}
+		err = adapter.insertCanary(tableName, columns, value)
+		if err != nil {
+			return err
+		}
+		location = tableName + "." + strings.Join(columns, "+")
+	case *LocalFileAdapter:
+		info, err := os.Stat(urlStr[7:])
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("canary plant requires a directory for files")
+		}
+		location = filepath.Join(urlStr[7:], "pdscan-canary-"+id+".txt")
+		err = os.WriteFile(location, []byte("Canary record: "+value+"\n"), 0644)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("canary plant is only supported for SQL databases and local directories")
+	}
+
+	state.Canaries = append(state.Canaries, canary{Id: id, Value: value, Source: redactUrl(urlStr), Location: location, PlantedAt: time.Now().UTC()})
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(canariesFile, append(data, '\n'))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Planted canary %s in %s\n", id, location)
+	fmt.Fprintln(output, value)
+	return nil
+}
+
+// tables can include the schema, like public.users
+func (a SqlAdapter) insertCanary(tableName string, columns []string, value string) error {
+	t := table{Name: tableName}
+	if i := strings.LastIndexByte(tableName, '.'); i != -1 {
+		t = table{Schema: tableName[:i], Name: tableName[i+1:]}
+	}
+
+	ref := a.quoteColumn(t.Name)
+	if t.Schema != "" {
+		ref = a.tableRef(t)
+	}
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		quoted[i] = a.quoteColumn(column)
+		placeholders[i] = "?"
+		args[i] = value
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", ref, strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	_, err := a.DB.Exec(a.DB.Rebind(sql), args...)
+	return err
+}
+
+// CheckCanaries scans for planted canaries and reports where each was found
+// tables are sampled, so canaries in large tables may need a larger sample size
+func CheckCanaries(urlStr string, canariesFile string, limit int, format string, output io.Writer) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("Invalid format: %s\nValid formats are json, text", format)
+	}
+
+	state, err := loadCanaries(canariesFile)
+	if err != nil {
+		return err
+	}
+	if len(state.Canaries) == 0 {
+		return fmt.Errorf("No canaries in %s", canariesFile)
+	}
+
+	values := make([]string, len(state.Canaries))
+	for i, c := range state.Canaries {
+		values[i] = regexp.QuoteMeta(c.Value)
+	}
+	matchConfig := MatchConfig{
+		RegexRules: []regexRule{{Name: "canary", DisplayName: "canaries", Confidence: "high", Regex: regexp.MustCompile(strings.Join(values, "|"))}},
+		MinCount:   1,
+	}
+
+	adapter := newAdapter(urlStr, "")
+	matchList, err := adapter.Scan(ScanOpts{
+		UrlStr:      urlStr,
+		ShowAll:     true,
+		Limit:       limit,
+		Formatter:   discardFormatter{},
+		MatchConfig: &matchConfig,
+		Coverage:    &coverage{},
+	})
+	if err != nil {
+		return err
+	}
+
+	results := make([]canaryResult, len(state.Canaries))
+	for i, c := range state.Canaries {
+		results[i] = canaryResult{canary: c, FoundIn: []string{}}
+		for _, match := range matchList {
+			for _, v := range match.MatchedData {
+				if strings.Contains(v, c.Value) {
+					results[i].FoundIn = append(results[i].FoundIn, match.Identifier)
+					break
+				}
+			}
+		}
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	for _, r := range results {
+		fmt.Fprintf(output, "canary %s (planted in %s %s):\n", r.Id, r.Source, r.Location)
+		if len(r.FoundIn) == 0 {
+			fmt.Fprintln(output, "    not found")
+		}
+		for _, identifier := range r.FoundIn {
+			fmt.Fprintf(output, "    %s\n", identifier)
+		}
+	}
+	return nil
+}
+
+func loadCanaries(filename string) (*canaryState, error) {
+	state := &canaryState{Canaries: []canary{}}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid canaries file %s: %s", filename, err)
+	}
+	return state, nil
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type cardBrand struct {
+	Name        string
+	DisplayName string
+	// BIN prefixes, like 4, or inclusive ranges of prefixes with the same length, like 2221-2720
+	Prefixes []string
+	Lengths  []int
+}
+
+// more specific ranges come first, since Discover cards can start with 62
+// https://en.wikipedia.org/wiki/Payment_card_number#Issuer_identification_number_(IIN)
+var cardBrands = []cardBrand{
+	{Name: "amex", DisplayName: "American Express", Prefixes: []string{"34", "37"}, Lengths: []int{15}},
+	{Name: "diners_club", DisplayName: "Diners Club", Prefixes: []string{"300-305", "36", "38-39"}, Lengths: []int{14, 15, 16, 17, 18, 19}},
+	{Name: "discover", DisplayName: "Discover", Prefixes: []string{"6011", "622126-622925", "644-649", "65"}, Lengths: []int{16, 17, 18, 19}},
+	{Name: "jcb", DisplayName: "JCB", Prefixes: []string{"3528-3589"}, Lengths: []int{16, 17, 18, 19}},
+	{Name: "mastercard", DisplayName: "Mastercard", Prefixes: []string{"2221-2720", "51-55"}, Lengths: []int{16}},
+	{Name: "unionpay", DisplayName: "UnionPay", Prefixes: []string{"62", "81"}, Lengths: []int{16, 17, 18, 19}},
+	{Name: "visa", DisplayName: "Visa", Prefixes: []string{"4"}, Lengths: []int{13, 16, 19}},
+}
+
+func (b cardBrand) matches(digits string) bool {
+	lengthMatches := false
+	for _, length := range b.Lengths {
+		if len(digits) == length {
+			lengthMatches = true
+		}
+	}
+	if !lengthMatches {
+		return false
+	}
+
+	for _, prefix := range b.Prefixes {
+		low, high, found := strings.Cut(prefix, "-")
+		if !found {
+			high = low
+		}
+		// prefixes in a range have the same number of digits, so they compare as strings
+		bin := digits[:len(low)]
+		if bin >= low && bin <= high {
+			return true
+		}
+	}
+	return false
+}
+
+// the brand of a card number, or nil if the BIN is not for a known brand
+func findCardBrand(digits string) *cardBrand {
+	for i := range cardBrands {
+		if cardBrands[i].matches(digits) {
+			return &cardBrands[i]
+		}
+	}
+	return nil
+}
+
+func cardDigits(v string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, v)
+}
+
+// separators are ignored, and the number must have a known BIN and pass the Luhn check
+func validCardNumber(v string) bool {
+	digits := cardDigits(v)
+	return findCardBrand(digits) != nil && luhnValid(digits)
+}
+
+// unique card numbers for each brand
+func cardBrandCounts(values []string) map[string]int {
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, v := range values {
+		digits := cardDigits(v)
+		if seen[digits] {
+			continue
+		}
+		seen[digits] = true
+
+		if brand := findCardBrand(digits); brand != nil {
+			counts[brand.Name]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	return counts
+}
+
+// like 2 Visa, 1 Mastercard
+func cardBrandSummary(counts map[string]int) string {
+	brands := []cardBrand{}
+	for _, brand := range cardBrands {
+		if counts[brand.Name] > 0 {
+			brands = append(brands, brand)
+		}
+	}
+	sort.SliceStable(brands, func(i, j int) bool {
+		return counts[brands[i].Name] > counts[brands[j].Name]
+	})
+
+	parts := make([]string, len(brands))
+	for i, brand := range brands {
+		parts[i] = fmt.Sprintf("%d %s", counts[brand.Name], brand.DisplayName)
+	}
+	return strings.Join(parts, ", ")
+}
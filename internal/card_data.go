@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// track 1 is the card number, name, expiration, and service code, like %B4111111111111111^DOE/JANE^2512101
+// track 2 is the card number, expiration, and service code, like ;4111111111111111=2512101
+var trackDataRegex = regexp.MustCompile(`%?B\d{13,19}\^[A-Za-z /.'-]{2,26}\^\d{7}|;?\b\d{13,19}=\d{7}`)
+
+// CVVs are 3 digits, or 4 for American Express
+var cvvRegex = regexp.MustCompile(`^\d{3,4}$`)
+
+// the card number must pass the Luhn check
+func validTrackData(v string) bool {
+	v = strings.TrimLeft(v, "%B;")
+	end := strings.IndexAny(v, "^=")
+	if end == -1 {
+		return false
+	}
+	return luhnValid(v[:end])
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// sampled bytes of each column, like large documents or images in a BLOB column
+// text values are truncated at the budget, so the start of each column is still checked
+// binary values over the budget are not checked, and names are still checked, like with masked columns
+type columnBudget struct {
+	budget    int64
+	sizes     []int64
+	binary    []bool
+	truncated []bool
+}
+
+func newColumnBudget(budget int64) *columnBudget {
+	return &columnBudget{budget: budget}
+}
+
+// returns the part of the value within the budget left for column i, and false if none is left
+func (b *columnBudget) add(i int, value string) (string, bool) {
+	for len(b.sizes) <= i {
+		b.sizes = append(b.sizes, 0)
+		b.binary = append(b.binary, false)
+		b.truncated = append(b.truncated, false)
+	}
+
+	if !b.binary[i] && isBinaryValue(value) {
+		b.binary[i] = true
+	}
+
+	left := b.budget - b.sizes[i]
+	if int64(len(value)) > left {
+		b.truncated[i] = true
+		value = truncateValue(value, int(left))
+	}
+	if value == "" {
+		return "", false
+	}
+	b.sizes[i] += int64(len(value))
+	return value, true
+}
+
+// applies the budget to values that were fetched all at once, like documents from an API
+func (b *columnBudget) apply(data *tableData) {
+	for i, values := range data.ColumnValues {
+		kept := []string{}
+		for _, value := range values {
+			if value, ok := b.add(i, value); ok {
+				kept = append(kept, value)
+			}
+		}
+		data.ColumnValues[i] = kept
+	}
+}
+
+// removes binary values over the budget and reports columns over the budget
+// returns the truncated columns, so matches for them can be marked
+func (b *columnBudget) finish(table table, data *tableData, c *coverage) map[string]bool {
+	truncated := make(map[string]bool)
+	for i, col := range data.ColumnNames {
+		if i >= len(b.truncated) || !b.truncated[i] {
+			continue
+		}
+
+		identifier := table.displayName() + "." + col
+		if table.displayName() == "" {
+			identifier = col
+		}
+		if b.binary[i] {
+			data.ColumnValues[i] = []string{}
+
+			detail := fmt.Sprintf("binary values exceed column budget of %s", formatSize(b.budget))
+			fmt.Fprintf(os.Stderr, "Skipped values of %s (%s)\n", identifier, detail)
+			c.skip(identifier, skipBinary, detail)
+		} else {
+			truncated[col] = true
+
+			detail := fmt.Sprintf("values truncated at column budget of %s", formatSize(b.budget))
+			fmt.Fprintf(os.Stderr, "Truncated values of %s (%s)\n", identifier, detail)
+			c.skip(identifier, skipTooLarge, detail)
+		}
+	}
+	return truncated
+}
+
+// marks matches for columns with truncated values, since data past the budget was not checked
+func markTruncated(matchList []ruleMatch, table table, truncated map[string]bool) {
+	if len(truncated) == 0 {
+		return
+	}
+	prefix := ""
+	if table.displayName() != "" {
+		prefix = table.displayName() + "."
+	}
+	for i, match := range matchList {
+		if !strings.HasPrefix(match.Identifier, prefix) {
+			continue
+		}
+		// multiple columns, like lat+lon
+		for _, col := range strings.Split(strings.TrimPrefix(match.Identifier, prefix), "+") {
+			if truncated[col] {
+				matchList[i].Truncated = true
+			}
+		}
+	}
+}
+
+// cuts at a character boundary, so truncated text is still valid UTF-8
+func truncateValue(value string, size int) string {
+	if size <= 0 {
+		return ""
+	}
+	if len(value) <= size {
+		return value
+	}
+	end := size
+	for i := 1; i < utf8.UTFMax && end > 0 && !utf8.RuneStart(value[end]); i++ {
+		end--
+	}
+	return value[:end]
+}
+
+func isBinaryValue(value string) bool {
+	return strings.IndexByte(value, 0) != -1 || !utf8.ValidString(value)
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// columnCommentAdapter is implemented by adapters that can read column comments
+// so schema owners can annotate columns, like pdscan:ignore or pdscan:pii=email
+type columnCommentAdapter interface {
+	FetchColumnComments(ctx context.Context, table table) (map[string]string, error)
+}
+
+type columnComment struct {
+	Column  string `db:"column_name"`
+	Comment string `db:"column_comment"`
+}
+
+var columnHintRegex = regexp.MustCompile(`pdscan:(ignore|pii=(\w+))`)
+
+// annotation from a column comment
+type columnHint struct {
+	Ignore bool
+	Rule   string
+}
+
+func parseColumnHints(comments map[string]string) map[string]columnHint {
+	hints := make(map[string]columnHint)
+	for col, comment := range comments {
+		match := columnHintRegex.FindStringSubmatch(comment)
+		if match == nil {
+			continue
+		}
+		if match[1] == "ignore" {
+			hints[col] = columnHint{Ignore: true}
+		} else {
+			hints[col] = columnHint{Rule: match[2]}
+		}
+	}
+	return hints
+}
+
+// ignored columns are not reported and forced rules replace other matches for the column
+func applyColumnHints(table table, tableData *tableData, comments map[string]string, matchList []ruleMatch, matchConfig *MatchConfig, c *coverage) []ruleMatch {
+	hints := parseColumnHints(comments)
+	if len(hints) == 0 {
+		return matchList
+	}
+
+	prefix := ""
+	if table.displayName() != "" {
+		prefix = table.displayName() + "."
+	}
+
+	found := make(map[string]bool)
+	newMatchList := []ruleMatch{}
+	for _, match := range matchList {
+		keep := true
+		for _, col := range matchColumns(match, prefix) {
+			hint, ok := hints[col]
+			if !ok {
+				continue
+			}
+			if hint.Ignore {
+				keep = false
+			} else if hint.Rule == match.RuleName {
+				match.Confidence = "high"
+				match.rowCount = 0
+				found[col] = true
+			} else {
+				keep = false
+			}
+		}
+		if keep {
+			newMatchList = append(newMatchList, match)
+		}
+	}
+
+	knownNames := knownRuleNames()
+	validNames := makeValidNames(matchConfig)
+	for _, col := range sortedKeys(hints) {
+		hint := hints[col]
+		if hint.Ignore {
+			c.skip(prefix+col, skipExcluded, "excluded by column comment")
+		} else if found[col] {
+			continue
+		} else if !knownNames[hint.Rule] && !validNames[hint.Rule] {
+			fmt.Fprintf(os.Stderr, "Unknown rule in comment on %s%s: %s\n", prefix, col, hint.Rule)
+		} else if validNames[hint.Rule] {
+			var values []string
+			for i, name := range tableData.ColumnNames {
+				if name == col {
+					values = tableData.ColumnValues[i]
+				}
+			}
+			newMatchList = append(newMatchList, ruleMatch{RuleName: hint.Rule, DisplayName: ruleDisplayName(matchConfig, hint.Rule), Confidence: "high", Identifier: prefix + col, MatchedData: values, MatchType: "comment"})
+		}
+	}
+
+	return newMatchList
+}
+
+// columns of a match, including JSON columns like col.key and multiple columns like lat+lon
+func matchColumns(match ruleMatch, prefix string) []string {
+	if !strings.HasPrefix(match.Identifier, prefix) {
+		return nil
+	}
+
+	cols := strings.Split(strings.TrimPrefix(match.Identifier, prefix), "+")
+	for i, col := range cols {
+		cols[i] = strings.SplitN(col, ".", 2)[0]
+	}
+	return cols
+}
+
+func sortedKeys(hints map[string]columnHint) []string {
+	keys := make([]string, 0, len(hints))
+	for key := range hints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// how values are scanned based on the database type
+const (
+	textColumn = iota
+	jsonColumn
+	integerColumn
+	skipColumn
+)
+
+var integerTypes = []string{"INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "HUGEINT", "UINT", "UINTEGER", "UTINYINT", "USMALLINT", "UBIGINT", "UHUGEINT", "SERIAL", "SMALLSERIAL", "BIGSERIAL", "DECIMAL", "NUMERIC", "BIGNUMERIC", "NUMBER"}
+
+func (d *tableData) columnKind(i int) int {
+	if i >= len(d.ColumnTypes) {
+		return textColumn
+	}
+
+	ty := d.ColumnTypes[i]
+	if hasTypeName(ty, []string{"JSON", "JSONB"}) {
+		return jsonColumn
+	}
+	if isTextType(ty) {
+		return textColumn
+	}
+	if hasTypeName(ty, []string{"INTERVAL"}) {
+		return skipColumn
+	}
+	if hasTypeName(ty, integerTypes) {
+		return integerColumn
+	}
+	// booleans, floats, dates, and times
+	return skipColumn
+}
+
+// matches whole words of the type, so POINT isn't an INT
+// sizes are ignored, like INT8, VARCHAR2, or Nullable(Int64)
+func hasTypeName(ty string, names []string) bool {
+	words := strings.FieldsFunc(strings.ToUpper(ty), func(r rune) bool {
+		return (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	})
+	for _, word := range words {
+		word = strings.TrimRight(word, "0123456789")
+		if stringInSlice(word, names) {
+			return true
+		}
+	}
+	return false
+}
+
+// scans JSON objects by key, like settings.email
+// other JSON values are scanned as text
+func expandJsonColumns(d *tableData) *tableData {
+	hasJson := false
+	for i := range d.ColumnNames {
+		if d.columnKind(i) == jsonColumn {
+			hasJson = true
+		}
+	}
+	if !hasJson {
+		return d
+	}
+
+	keyMap := make(map[string]int)
+	columnValues := make([][]string, 0)
+	// expanded keys are inserted between columns, so types are looked up by name
+	typeMap := make(map[string]string)
+
+	for i, col := range d.ColumnNames {
+		keyMap[col] = len(columnValues)
+		if d.columnKind(i) != jsonColumn {
+			columnValues = append(columnValues, d.ColumnValues[i])
+			if i < len(d.ColumnTypes) {
+				typeMap[col] = d.ColumnTypes[i]
+			}
+			continue
+		}
+
+		columnValues = append(columnValues, []string{})
+
+		for _, value := range d.ColumnValues[i] {
+			var object map[string]interface{}
+			if json.Unmarshal([]byte(value), &object) == nil {
+				keyMap, columnValues = scanSource(object, col+".", keyMap, columnValues)
+			} else {
+				j := keyMap[col]
+				columnValues[j] = append(columnValues[j], value)
+			}
+		}
+	}
+
+	columnNames := make([]string, len(keyMap))
+	columnTypes := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+		columnTypes[i] = typeMap[key]
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, ColumnTypes: columnTypes, RowCount: d.RowCount}
+}
+
+// integers lose formatting, so check digits directly
+func (a *MatchFinder) checkIntegers(colIdentifier string, values []string) []ruleMatch {
+	matchList := []ruleMatch{}
+
+	for _, rule := range a.matchConfig.RegexRules {
+		var valid func(string) bool
+		switch rule.Name {
+		case "ssn":
+			valid = func(v string) bool { return len(v) == 9 && validSsn(v) }
+		case "credit_card":
+			valid = validCardNumber
+		default:
+			continue
+		}
+
+		matchedData := []string{}
+		for _, v := range values {
+			if isDigits(v) && valid(v) {
+				matchedData = append(matchedData, v)
+			}
+		}
+
+		lineCount := len(matchedData)
+		if lineCount > 0 && lineCount >= a.matchConfig.MinCount {
+			confidence := "low"
+			if float64(lineCount)/float64(len(values)) > 0.5 {
+				confidence = "high"
+			}
+			var brands map[string]int
+			if rule.Name == "credit_card" {
+				brands = cardBrandCounts(matchedData)
+			}
+			matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: confidence, Identifier: colIdentifier, MatchedData: matchedData, LineCount: lineCount, MatchType: "value", Brands: brands})
+		}
+	}
+
+	return matchList
+}
+
+func isDigits(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, c := range v {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func luhnValid(v string) bool {
+	sum := 0
+	double := false
+	for i := len(v) - 1; i >= 0; i-- {
+		d := int(v[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// bare digits in text columns only count when the column name says what they are
+func (a *MatchFinder) checkHintedIntegers(colIdentifier string, col string, values []string, matchList []ruleMatch) []ruleMatch {
+	for _, match := range a.checkIntegers(colIdentifier, values) {
+		rule := findRegexRule(match.RuleName, a.matchConfig.RegexRules)
+		if rule == nil || !matchesColumnHint(col, rule.ColumnHints) || hasRuleMatch(matchList, match.RuleName) {
+			continue
+		}
+		matchList = append(matchList, match)
+	}
+	return matchList
+}
+
+func hasRuleMatch(matchList []ruleMatch, name string) bool {
+	for _, match := range matchList {
+		if match.RuleName == name {
+			return true
+		}
+	}
+	return false
+}
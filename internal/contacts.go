@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// max length of an unfolded vCard or iCalendar line
+const contentLineLimit = 16 * 1024 * 1024
+
+// components scanned as records, like a contact or a meeting
+var contentRecords = []string{"VCARD", "VEVENT", "VTODO", "VJOURNAL"}
+
+// properties that are metadata, identifiers that look like emails, or binary data
+var contentSkipProperties = []string{"BEGIN", "END", "VERSION", "PRODID", "UID", "CALSCALE", "METHOD", "DTSTAMP", "DTSTART", "DTEND", "DUE", "CREATED", "LAST-MODIFIED", "REV", "SEQUENCE", "STATUS", "TRANSP", "RRULE", "EXDATE", "RDATE", "TZID", "TZOFFSETFROM", "TZOFFSETTO", "TZNAME", "PHOTO", "LOGO", "SOUND", "KEY"}
+
+// properties with a meaning that name rules can use
+var contentPropertyNames = map[string]string{
+	"TEL":  "phone",
+	"N":    "surname",
+	"BDAY": "birthday",
+}
+
+var contentLineEscapes = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+var contentLineStart = regexp.MustCompile(`(?i)^BEGIN:(VCARD|VCALENDAR)\r?$`)
+
+// checks if the first line starts a vCard or iCalendar file
+func isContentLines(head []byte) bool {
+	head = bytes.TrimLeft(head, "\ufeff \t\r\n")
+	i := bytes.IndexByte(head, '\n')
+	if i == -1 {
+		i = len(head)
+	}
+	return contentLineStart.Match(head[:i])
+}
+
+// vCard and iCalendar files are scanned by property, like VCARD.EMAIL or VEVENT.ATTENDEE
+// each contact or event counts as a line
+func processContentLines(reader io.Reader, matchFinder *MatchFinder) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, chunkSize), contentLineLimit)
+
+	var components []string
+	var line string
+
+	process := func(line string) {
+		name, params, value := parseContentLine(line)
+		switch name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(value))
+		case "END":
+			if len(components) > 0 {
+				if stringInSlice(components[len(components)-1], contentRecords) {
+					matchFinder.Count += 1
+				}
+				components = components[:len(components)-1]
+			}
+		}
+		if name == "" || stringInSlice(name, contentSkipProperties) {
+			return
+		}
+
+		// nested components, like alarms, are part of the record
+		component := "VCALENDAR"
+		for _, c := range components {
+			if stringInSlice(c, contentRecords) {
+				component = c
+				break
+			}
+		}
+		matchFinder.scanContentProperty(component+"."+name, name, params, value)
+	}
+
+	for scanner.Scan() {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+
+		// long lines are folded onto lines starting with whitespace
+		next := strings.TrimRight(scanner.Text(), "\r")
+		if len(next) > 0 && (next[0] == ' ' || next[0] == '\t') {
+			line += next[1:]
+			continue
+		}
+
+		if line != "" {
+			process(line)
+		}
+		line = next
+	}
+	if line != "" {
+		process(line)
+	}
+
+	return scanner.Err()
+}
+
+func (a *MatchFinder) scanContentProperty(path string, name string, params map[string]string, value string) {
+	value = contentLineEscapes.Replace(value)
+
+	switch name {
+	case "N":
+		// family name is the first component
+		value = strings.SplitN(value, ";", 2)[0]
+	case "ADR":
+		value = strings.Join(strings.Fields(strings.Replace(value, ";", " ", -1)), " ")
+	case "ATTENDEE", "ORGANIZER":
+		value = strings.TrimPrefix(strings.TrimPrefix(value, "mailto:"), "MAILTO:")
+		if cn, ok := params["CN"]; ok {
+			a.scanField(path+".CN", cn)
+		}
+	case "GEO":
+		// geo:lat,lon in vCard 4 and lat;lon otherwise
+		parts := strings.FieldsFunc(strings.TrimPrefix(value, "geo:"), func(r rune) bool { return r == ',' || r == ';' })
+		if len(parts) >= 2 {
+			a.scanCoordinates(path, []string{parts[1] + " " + parts[0]})
+		}
+		return
+	}
+
+	if value == "" {
+		return
+	}
+	a.scanNamedField(path, contentPropertyNames[name], value)
+}
+
+// splits NAME;PARAM=value:value, ignoring groups like item1.EMAIL
+func parseContentLine(line string) (string, map[string]string, string) {
+	params := make(map[string]string)
+
+	// colons in quoted parameter values are not separators
+	quoted := false
+	end := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return "", params, ""
+	}
+
+	parts := strings.Split(line[:end], ";")
+	name := strings.ToUpper(parts[0])
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		name = name[i+1:]
+	}
+
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return name, params, line[end+1:]
+}
@@ -0,0 +1,359 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var corpusFormats = []string{"csv", "jsonl", "parquet", "sql"}
+
+// fields of generated records, with the JSON path and the rule of planted values
+// fields without a rule only have values that should not match, like order numbers
+var corpusFields = []struct {
+	name string
+	path string
+	rule string
+}{
+	{"id", "$.id", ""},
+	{"email", "$.user.email", "email"},
+	{"phone", "$.user.phone", "phone"},
+	{"last_name", "$.user.last_name", "surname"},
+	{"ssn", "$.ssn", "ssn"},
+	{"card", "$.payment.card", "credit_card"},
+	{"ip", "$.ip", "ip"},
+	{"street", "$.address.street", "street"},
+	{"notes", "$.notes", ""},
+	{"reference", "$.reference", ""},
+	{"amount", "$.amount", ""},
+	{"created_at", "$.created_at", ""},
+}
+
+var corpusStreets = []string{"Main St", "Oak Avenue", "Elm Street", "Park Ave", "Lake Road", "Hill Drive"}
+
+var corpusNotes = []string{"Order shipped on time", "Customer asked about pricing", "Renewal due next quarter", "Refund approved", "Upgraded to the pro plan"}
+
+// labels are the rules that should be found for each identifier
+// identifiers are relative to the corpus directory, like pdscan reports them for file://corpus/
+type corpusLabels struct {
+	Rows      int           `json:"rows"`
+	Seed      int64         `json:"seed"`
+	MatchRate float64       `json:"match_rate"`
+	Labels    []corpusLabel `json:"labels"`
+}
+
+type corpusLabel struct {
+	Identifier string `json:"identifier"`
+	Rule       string `json:"rule"`
+	// rows with a planted value
+	Count int `json:"count"`
+}
+
+// a generated record, with the rules planted in each field
+type corpusRecord struct {
+	values []string
+	rules  [][]string
+}
+
+// GenerateCorpus writes synthetic data with planted values and labels, so rule changes can be measured
+// data is generated, so no real personal data is included
+func GenerateCorpus(dir string, rows int, formats string, seed int64, matchRate float64) error {
+	if rows < 1 {
+		return fmt.Errorf("rows must be positive")
+	}
+	if matchRate < 0 || matchRate > 1 {
+		return fmt.Errorf("match-rate must be between 0 and 1")
+	}
+
+	formatList := []string{}
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.TrimSpace(format)
+		if !stringInSlice(format, corpusFormats) {
+			return fmt.Errorf("Invalid corpus format: %s\nValid formats are %s", format, strings.Join(corpusFormats, ", "))
+		}
+		formatList = append(formatList, format)
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	labels := []corpusLabel{}
+	for _, format := range formatList {
+		// the same seed for each format, so they have the same records
+		random := rand.New(rand.NewSource(seed))
+		filename := "corpus." + format
+
+		counts, err := writeCorpusFile(filepath.Join(dir, filename), format, rows, random, matchRate)
+		if err != nil {
+			return err
+		}
+
+		identifiers := make([]string, 0, len(counts))
+		for identifier := range counts {
+			identifiers = append(identifiers, identifier)
+		}
+		sort.Strings(identifiers)
+
+		for _, identifier := range identifiers {
+			rules := make([]string, 0, len(counts[identifier]))
+			for rule := range counts[identifier] {
+				rules = append(rules, rule)
+			}
+			sort.Strings(rules)
+
+			for _, rule := range rules {
+				id := filename
+				if identifier != "" {
+					id += " " + identifier
+				}
+				labels = append(labels, corpusLabel{Identifier: id, Rule: rule, Count: counts[identifier][rule]})
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", filepath.Join(dir, filename))
+	}
+
+	data, err := json.MarshalIndent(corpusLabels{Rows: rows, Seed: seed, MatchRate: matchRate, Labels: labels}, "", "  ")
+	if err != nil {
+		return err
+	}
+	labelsFile := filepath.Join(dir, "labels.json")
+	err = writeFileAtomic(labelsFile, append(data, '\n'))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", labelsFile)
+	return nil
+}
+
+// returns planted counts by identifier and rule
+// JSON lines are labeled by field and other formats by file, like pdscan reports them
+func writeCorpusFile(filename string, format string, rows int, random *rand.Rand, matchRate float64) (map[string]map[string]int, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	writer := bufio.NewWriter(f)
+	csvWriter := csv.NewWriter(writer)
+
+	columns := make([]string, len(corpusFields))
+	for i, field := range corpusFields {
+		columns[i] = field.name
+	}
+
+	var parquetWriter *corpusParquetWriter
+	switch format {
+	case "csv":
+		err = csvWriter.Write(columns)
+	case "parquet":
+		parquetWriter, err = newCorpusParquetWriter(writer, columns)
+	case "sql":
+		_, err = fmt.Fprintf(writer, "CREATE TABLE people (%s text);\n", strings.Join(columns, " text, "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]map[string]int)
+	for i := 1; i <= rows; i++ {
+		record := generateCorpusRecord(i, random, matchRate)
+
+		switch format {
+		case "csv":
+			err = csvWriter.Write(record.values)
+		case "jsonl":
+			err = writeCorpusJson(writer, record)
+		case "parquet":
+			err = parquetWriter.write(record)
+		case "sql":
+			err = writeCorpusSql(writer, record)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// count each rule once per row for files
+		rowRules := make(map[string]bool)
+		for j, rules := range record.rules {
+			for _, rule := range rules {
+				if format == "jsonl" {
+					addCorpusCount(counts, corpusFields[j].path, rule)
+				} else if !rowRules[rule] {
+					addCorpusCount(counts, "", rule)
+					rowRules[rule] = true
+				}
+			}
+		}
+	}
+
+	if parquetWriter != nil {
+		err = parquetWriter.close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	csvWriter.Flush()
+	err = csvWriter.Error()
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return counts, f.Close()
+}
+
+func addCorpusCount(counts map[string]map[string]int, identifier string, rule string) {
+	if counts[identifier] == nil {
+		counts[identifier] = make(map[string]int)
+	}
+	counts[identifier][rule]++
+}
+
+func generateCorpusRecord(id int, random *rand.Rand, matchRate float64) corpusRecord {
+	record := corpusRecord{values: make([]string, len(corpusFields)), rules: make([][]string, len(corpusFields))}
+	plant := func() bool { return random.Float64() < matchRate }
+
+	for i, field := range corpusFields {
+		var value string
+		var rules []string
+
+		switch field.name {
+		case "id":
+			value = strconv.Itoa(id)
+		case "notes":
+			value = corpusNotes[random.Intn(len(corpusNotes))]
+			if plant() {
+				value += ", contact " + corpusEmail(random)
+				rules = append(rules, "email")
+			}
+			if plant() {
+				value += ", call " + corpusPhone(random)
+				rules = append(rules, "phone")
+			}
+		case "reference":
+			// values that look like other data, like invalid SSNs and version numbers
+			switch random.Intn(4) {
+			case 0:
+				value = fmt.Sprintf("ORD-%06d", random.Intn(1000000))
+			case 1:
+				value = fmt.Sprintf("666-%02d-%04d", 1+random.Intn(99), 1+random.Intn(9999))
+			case 2:
+				value = fmt.Sprintf("v%d.%d.%d", random.Intn(10), random.Intn(100), random.Intn(1000))
+			default:
+				value = fmt.Sprintf("Ticket 800-%07d", random.Intn(10000000))
+			}
+		case "amount":
+			value = fmt.Sprintf("%d.%02d", random.Intn(1000), random.Intn(100))
+		case "created_at":
+			value = fmt.Sprintf("2024-%02d-%02d", 1+random.Intn(12), 1+random.Intn(28))
+		default:
+			if plant() {
+				value = corpusValue(field.rule, random)
+				rules = append(rules, field.rule)
+			}
+		}
+
+		record.values[i] = value
+		record.rules[i] = rules
+	}
+	return record
+}
+
+// values are fictional, like example.org emails, 555-01XX phone numbers, and documentation IP ranges
+func corpusValue(rule string, random *rand.Rand) string {
+	switch rule {
+	case "email":
+		return corpusEmail(random)
+	case "phone":
+		return corpusPhone(random)
+	case "surname":
+		name := lastNames[random.Intn(len(lastNames))].(string)
+		return strings.ToUpper(name[:1]) + name[1:]
+	case "ssn":
+		for {
+			ssn := fmt.Sprintf("%03d-%02d-%04d", 1+random.Intn(899), 1+random.Intn(99), 1+random.Intn(9999))
+			if validSsn(ssn) {
+				return ssn
+			}
+		}
+	case "credit_card":
+		return corpusCard(random)
+	case "ip":
+		prefixes := []string{"192.0.2", "198.51.100", "203.0.113"}
+		return fmt.Sprintf("%s.%d", prefixes[random.Intn(len(prefixes))], 1+random.Intn(254))
+	case "street":
+		return fmt.Sprintf("%d %s", 1+random.Intn(9999), corpusStreets[random.Intn(len(corpusStreets))])
+	}
+	return ""
+}
+
+func corpusEmail(random *rand.Rand) string {
+	return fmt.Sprintf("user%d@example.org", random.Intn(1000000))
+}
+
+func corpusPhone(random *rand.Rand) string {
+	return fmt.Sprintf("%d-555-01%02d", 201+random.Intn(700), random.Intn(100))
+}
+
+// 16 digits starting with 4, with a valid check digit
+func corpusCard(random *rand.Rand) string {
+	digits := []byte{'4'}
+	for len(digits) < 15 {
+		digits = append(digits, byte('0'+random.Intn(10)))
+	}
+	for d := byte('0'); d <= '9'; d++ {
+		if luhnValid(string(append(digits, d))) {
+			digits = append(digits, d)
+			break
+		}
+	}
+	card := string(digits)
+	return card[0:4] + "-" + card[4:8] + "-" + card[8:12] + "-" + card[12:16]
+}
+
+func writeCorpusJson(writer *bufio.Writer, record corpusRecord) error {
+	doc := make(map[string]interface{})
+	for i, field := range corpusFields {
+		// nest by path, like $.user.email
+		parts := strings.Split(strings.TrimPrefix(field.path, "$."), ".")
+		parent := doc
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := parent[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[part] = child
+			}
+			parent = child
+		}
+		parent[parts[len(parts)-1]] = record.values[i]
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
+
+func writeCorpusSql(writer *bufio.Writer, record corpusRecord) error {
+	values := make([]string, len(record.values))
+	for i, value := range record.values {
+		values[i] = "'" + strings.Replace(value, "'", "''", -1) + "'"
+	}
+	_, err := fmt.Fprintf(writer, "INSERT INTO people VALUES (%s);\n", strings.Join(values, ", "))
+	return err
+}
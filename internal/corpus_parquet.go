@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"io"
+
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/memory"
+	"github.com/apache/arrow/go/v14/parquet"
+	"github.com/apache/arrow/go/v14/parquet/compress"
+	"github.com/apache/arrow/go/v14/parquet/pqarrow"
+)
+
+// rows are buffered and written as a row group
+const corpusParquetRowGroup = 10000
+
+// string columns, uncompressed and without dictionaries, so values are stored as text
+type corpusParquetWriter struct {
+	writer  *pqarrow.FileWriter
+	builder *array.RecordBuilder
+	rows    int
+}
+
+func newCorpusParquetWriter(w io.Writer, columns []string) (*corpusParquetWriter, error) {
+	fields := make([]arrow.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = arrow.Field{Name: column, Type: arrow.BinaryTypes.String}
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Uncompressed), parquet.WithDictionaryDefault(false))
+	writer, err := pqarrow.NewFileWriter(schema, w, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	return &corpusParquetWriter{writer: writer, builder: array.NewRecordBuilder(memory.DefaultAllocator, schema)}, nil
+}
+
+func (w *corpusParquetWriter) write(record corpusRecord) error {
+	for i, value := range record.values {
+		w.builder.Field(i).(*array.StringBuilder).Append(value)
+	}
+	w.rows++
+	if w.rows == corpusParquetRowGroup {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *corpusParquetWriter) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	rec := w.builder.NewRecord()
+	defer rec.Release()
+	w.rows = 0
+	return w.writer.Write(rec)
+}
+
+func (w *corpusParquetWriter) close() error {
+	defer w.builder.Release()
+	err := w.flush()
+	if err != nil {
+		return err
+	}
+	return w.writer.Close()
+}
@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CosmosdbAdapter scans Azure Cosmos DB accounts using the SQL API
+type CosmosdbAdapter struct {
+	endpoint string
+	key      []byte
+	database string
+	client   *http.Client
+}
+
+// properties added to every item by Cosmos DB
+var cosmosdbSystemProperties = []string{"_rid", "_self", "_etag", "_attachments", "_ts"}
+
+func (a *CosmosdbAdapter) TableName() string {
+	return "container"
+}
+
+func (a *CosmosdbAdapter) RowName() string {
+	return "item"
+}
+
+func (a *CosmosdbAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *CosmosdbAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	database := ""
+	if len(u.Path) > 1 {
+		database = u.Path[1:]
+	}
+
+	// keys contain characters that must be escaped in URLs, so allow an environment variable
+	key, ok := u.User.Password()
+	if !ok {
+		key = os.Getenv("COSMOSDB_KEY")
+	}
+	if key == "" {
+		return errors.New("no key specified")
+	}
+
+	return a.initEndpoint("https://"+u.Host, database, key)
+}
+
+func (a *CosmosdbAdapter) initEndpoint(endpoint string, database string, key string) error {
+	var err error
+	a.key, err = base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return errors.New("invalid key")
+	}
+
+	a.endpoint = endpoint
+	a.database = database
+	a.client = &http.Client{Timeout: 30 * time.Second}
+
+	// check credentials
+	_, err = a.fetchDatabases()
+	return err
+}
+
+func (a CosmosdbAdapter) FetchTables() ([]table, error) {
+	tables := []table{}
+
+	var databases []string
+	if a.database != "" {
+		databases = []string{a.database}
+	} else {
+		var err error
+		databases, err = a.fetchDatabases()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, database := range databases {
+		var result struct {
+			DocumentCollections []struct {
+				Id string `json:"id"`
+			}
+		}
+		_, err := a.request(context.Background(), "colls", "dbs/"+database, nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, container := range result.DocumentCollections {
+			tables = append(tables, table{Schema: database, Name: container.Id})
+		}
+	}
+
+	return tables, nil
+}
+
+func (a CosmosdbAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	keyMap := make(map[string]int)
+
+	columnValues := make([][]string, 0)
+
+	// TODO randomize
+	count := 0
+	continuation := ""
+	for count < limit {
+		headers := map[string]string{"x-ms-max-item-count": strconv.Itoa(limit - count)}
+		if continuation != "" {
+			headers["x-ms-continuation"] = continuation
+		}
+
+		var result struct {
+			Documents []map[string]interface{}
+		}
+		resp, err := a.request(ctx, "docs", "dbs/"+table.Schema+"/colls/"+table.Name, headers, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range result.Documents {
+			for _, property := range cosmosdbSystemProperties {
+				delete(document, property)
+			}
+			keyMap, columnValues = scanSource(document, "", keyMap, columnValues)
+		}
+		count += len(result.Documents)
+
+		continuation = resp.Header.Get("x-ms-continuation")
+		if continuation == "" {
+			break
+		}
+	}
+
+	columnNames := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: count}, nil
+}
+
+func (a CosmosdbAdapter) fetchDatabases() ([]string, error) {
+	var result struct {
+		Databases []struct {
+			Id string `json:"id"`
+		}
+	}
+	_, err := a.request(context.Background(), "dbs", "", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	databases := []string{}
+	for _, database := range result.Databases {
+		databases = append(databases, database.Id)
+	}
+	return databases, nil
+}
+
+// https://learn.microsoft.com/en-us/rest/api/cosmos-db/access-control-on-cosmosdb-resources
+// the resource link is signed unescaped, but IDs can contain spaces and other characters, so escape them in the URL
+func (a CosmosdbAdapter) request(ctx context.Context, resourceType string, resourceLink string, headers map[string]string, result interface{}) (*http.Response, error) {
+	path := resourceType
+	if resourceLink != "" {
+		// IDs can't contain slashes
+		segments := strings.Split(resourceLink, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		path = strings.Join(segments, "/") + "/" + resourceType
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", a.endpoint+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	payload := "get\n" + resourceType + "\n" + resourceLink + "\n" + strings.ToLower(date) + "\n\n"
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(payload))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", url.QueryEscape("type=master&ver=1.0&sig="+signature))
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("x-ms-version", "2018-12-31")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return nil, fmt.Errorf("[%s] %s", resp.Status, strings.SplitN(e.Message, "\r\n", 2)[0])
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// reasons tables, files, and other objects are not scanned
+const (
+	skipExcluded         = "excluded"
+	skipBinary           = "binary"
+	skipTooLarge         = "too_large"
+	skipPermissionDenied = "permission_denied"
+	skipUnsupportedType  = "unsupported_type"
+	skipTimeBudget       = "time_budget"
+	skipTimeout          = "timeout"
+	skipFailed           = "failed"
+	skipNotSampled       = "not_sampled"
+)
+
+// coverage records what was and was not scanned
+// so reports can back up claims of scan completeness
+type coverage struct {
+	scanned []string
+	skipped []skippedObject
+	// rows, documents, keys, or lines
+	rows  int
+	mutex sync.Mutex
+}
+
+type skippedObject struct {
+	Identifier string `json:"identifier"`
+	Reason     string `json:"reason"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// coverageReporter is implemented by formatters that report what was not scanned
+type coverageReporter interface {
+	setCoverage(source string, c *coverage)
+}
+
+func (c *coverage) scan(identifier string, rows int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.scanned = append(c.scanned, identifier)
+	c.rows += rows
+}
+
+func (c *coverage) skip(identifier string, reason string, detail string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.skipped = append(c.skipped, skippedObject{Identifier: identifier, Reason: reason, Detail: detail})
+}
+
+func (c *coverage) Rows() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.rows
+}
+
+// objects are scanned in parallel, so sort for stable reports
+func (c *coverage) Scanned() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	scanned := append([]string{}, c.scanned...)
+	sort.Strings(scanned)
+	return scanned
+}
+
+func (c *coverage) Skipped() []skippedObject {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	skipped := append([]skippedObject{}, c.skipped...)
+	sort.SliceStable(skipped, func(i, j int) bool {
+		return skipped[i].Identifier < skipped[j].Identifier
+	})
+	return skipped
+}
+
+// CoverageFormatter prints what was and was not scanned as a JSON object after the scan.
+type CoverageFormatter struct {
+	source   string
+	coverage *coverage
+	labels   map[string]string
+}
+
+type coverageReport struct {
+	SchemaVersion int               `json:"schema_version"`
+	Source        string            `json:"source"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	ScannedCount  int               `json:"scanned_count"`
+	SkippedCount  int               `json:"skipped_count"`
+	Scanned       []string          `json:"scanned"`
+	Skipped       []skippedObject   `json:"skipped"`
+}
+
+func (f *CoverageFormatter) setCoverage(source string, c *coverage) {
+	f.source = redactUrl(source)
+	f.coverage = c
+}
+
+func (f *CoverageFormatter) setLabels(labels map[string]string) {
+	f.labels = labels
+}
+
+func (f *CoverageFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
+	return nil
+}
+
+func (f *CoverageFormatter) Finish(writer io.Writer) error {
+	scanned := f.coverage.Scanned()
+	skipped := f.coverage.Skipped()
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(coverageReport{
+		SchemaVersion: schemaVersion,
+		Source:        f.source,
+		Labels:        f.labels,
+		GeneratedAt:   time.Now().UTC(),
+		ScannedCount:  len(scanned),
+		SkippedCount:  len(skipped),
+		Scanned:       scanned,
+		Skipped:       skipped,
+	})
+}
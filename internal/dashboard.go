@@ -0,0 +1,132 @@
+package internal
+
+import (
+	_ "embed"
+	"net/http"
+	"sort"
+	"time"
+)
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// summaries have no matched data, so they are kept longer than results
+const runHistoryTTL = 30 * 24 * time.Hour
+
+// max summaries kept, so memory stays bounded for busy servers
+const maxRunHistory = 1000
+
+// max samples kept for each match
+const dashboardSampleLimit = 5
+
+// weights for risk scores, so a few critical matches outweigh many low confidence ones
+var riskWeights = map[string]int{"critical": 10, "high": 5, "medium": 2, "low": 1}
+
+type runSummary struct {
+	Id           string         `json:"id"`
+	Source       string         `json:"source"`
+	Status       string         `json:"status"`
+	CreatedAt    time.Time      `json:"created_at"`
+	FinishedAt   time.Time      `json:"finished_at"`
+	MatchesCount int            `json:"matches_count"`
+	Confidences  map[string]int `json:"confidences"`
+	RiskScore    int            `json:"risk_score"`
+}
+
+// latest completed scan of each source
+type sourceSummary struct {
+	Source       string    `json:"source"`
+	LastScanId   string    `json:"last_scan_id"`
+	LastScanAt   time.Time `json:"last_scan_at"`
+	Scans        int       `json:"scans"`
+	MatchesCount int       `json:"matches_count"`
+	RiskScore    int       `json:"risk_score"`
+}
+
+func riskScore(confidences map[string]int) int {
+	score := 0
+	for confidence, count := range confidences {
+		score += riskWeights[confidence] * count
+	}
+	return score
+}
+
+// must be called with the mutex held
+func (s *scanServer) recordRun(result scanResult) {
+	confidences := make(map[string]int)
+	for _, match := range result.Matches {
+		confidences[match.Confidence]++
+	}
+
+	s.runs = append(s.runs, runSummary{
+		Id:           result.Id,
+		Source:       result.Source,
+		Status:       result.Status,
+		CreatedAt:    result.CreatedAt,
+		FinishedAt:   *result.FinishedAt,
+		MatchesCount: result.MatchesCount,
+		Confidences:  confidences,
+		RiskScore:    riskScore(confidences),
+	})
+
+	// runs finish in order, so the oldest are first
+	start := 0
+	for start < len(s.runs) && (len(s.runs)-start > maxRunHistory || time.Since(s.runs[start].FinishedAt) > runHistoryTTL) {
+		start++
+	}
+	s.runs = s.runs[start:]
+}
+
+// newest first
+func (s *scanServer) listRuns(w http.ResponseWriter) {
+	s.mutex.Lock()
+	runs := make([]runSummary, len(s.runs))
+	for i, run := range s.runs {
+		runs[len(s.runs)-1-i] = run
+	}
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+}
+
+// highest risk first
+func (s *scanServer) listSources(w http.ResponseWriter) {
+	s.mutex.Lock()
+	bySource := make(map[string]*sourceSummary)
+	for _, run := range s.runs {
+		if run.Status != "completed" {
+			continue
+		}
+		summary, ok := bySource[run.Source]
+		if !ok {
+			summary = &sourceSummary{Source: run.Source}
+			bySource[run.Source] = summary
+		}
+		summary.Scans++
+		summary.LastScanId = run.Id
+		summary.LastScanAt = run.FinishedAt
+		summary.MatchesCount = run.MatchesCount
+		summary.RiskScore = run.RiskScore
+	}
+	s.mutex.Unlock()
+
+	sources := []sourceSummary{}
+	for _, summary := range bySource {
+		sources = append(sources, *summary)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].RiskScore != sources[j].RiskScore {
+			return sources[i].RiskScore > sources[j].RiskScore
+		}
+		return sources[i].Source < sources[j].Source
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sources": sources})
+}
+
+func serveDashboard(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// the page only talks to the server and can't be framed, since it holds the token
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.Write(dashboardHTML)
+}
@@ -1,9 +1,35 @@
 package internal
 
+import (
+	"context"
+	"time"
+)
+
 type DataStoreAdapter interface {
 	TableName() string
 	RowName() string
 	Init(url string) error
 	FetchTables() ([]table, error)
-	FetchTableData(table table, limit int) (*tableData, error)
+	// stops when ctx is canceled, like when the table times out
+	FetchTableData(ctx context.Context, table table, limit int) (*tableData, error)
+}
+
+// rowLimitAdapter is implemented by adapters that read rows one at a time,
+// so they can stop reading values or rows once over budget
+type rowLimitAdapter interface {
+	// returns the rows read so far and errTimeBudget if the deadline passes
+	FetchTableDataWithLimits(ctx context.Context, table table, limit int, limits rowLimits) (*tableData, error)
+}
+
+type rowLimits struct {
+	// bytes of each column, if set
+	columns *columnBudget
+	// time to stop reading rows, if set
+	deadline time.Time
+}
+
+// tableSizeAdapter is implemented by adapters that can estimate the rows of each table,
+// so the time budget can be split by size
+type tableSizeAdapter interface {
+	EstimateRows() map[string]int64
 }
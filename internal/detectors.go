@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Detector finds custom matches in the values of a column or file
+type Detector interface {
+	Detect(identifier string, values []string) ([]DetectorMatch, error)
+}
+
+type DetectorMatch struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"`
+	Confidence  string   `json:"confidence"`
+	Values      []string `json:"values"`
+}
+
+// max lines of each file passed to detectors
+const detectorLineLimit = 10000
+
+// SubprocessDetector runs an external command and exchanges newline delimited JSON
+// with it over stdin and stdout, one request and one response per line
+type SubprocessDetector struct {
+	command string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	mutex   sync.Mutex
+}
+
+type detectorRequest struct {
+	Identifier string   `json:"identifier"`
+	Values     []string `json:"values"`
+}
+
+type detectorResponse struct {
+	Matches []DetectorMatch `json:"matches"`
+	Error   string          `json:"error"`
+}
+
+func NewSubprocessDetector(command string) (*SubprocessDetector, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Invalid detector: %q", command)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("Error starting detector %s: %s", args[0], err)
+	}
+
+	return &SubprocessDetector{command: command, cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+func (d *SubprocessDetector) Detect(identifier string, values []string) ([]DetectorMatch, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	request, err := json.Marshal(detectorRequest{Identifier: identifier, Values: values})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = d.stdin.Write(append(request, '\n'))
+	if err != nil {
+		return nil, fmt.Errorf("Error writing to detector %s: %s", d.command, err)
+	}
+
+	line, err := d.stdout.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("Error reading from detector %s: %s", d.command, err)
+	}
+
+	var response detectorResponse
+	err = json.Unmarshal(line, &response)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid response from detector %s: %s", d.command, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("Error from detector %s: %s", d.command, response.Error)
+	}
+
+	return response.Matches, nil
+}
+
+func (d *SubprocessDetector) Close() error {
+	d.stdin.Close()
+	return d.cmd.Wait()
+}
+
+func detectValues(detectors []Detector, identifier string, values []string) ([]ruleMatch, error) {
+	matchList := []ruleMatch{}
+
+	if len(values) == 0 {
+		return matchList, nil
+	}
+
+	for _, detector := range detectors {
+		matches, err := detector.Detect(identifier, values)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			if match.Name == "" || len(match.Values) == 0 {
+				continue
+			}
+
+			displayName := match.DisplayName
+			if displayName == "" {
+				displayName = match.Name
+			}
+
+			confidence := match.Confidence
+			if confidence != "high" && confidence != "low" {
+				confidence = "medium"
+			}
+
+			matchList = append(matchList, ruleMatch{RuleName: match.Name, DisplayName: displayName, Confidence: confidence, Identifier: identifier, MatchedData: match.Values, LineCount: len(match.Values), MatchType: "value"})
+		}
+	}
+
+	return matchList, nil
+}
+
+func detectTableData(detectors []Detector, table table, tableData *tableData) ([]ruleMatch, error) {
+	matchList := []ruleMatch{}
+
+	for i, col := range tableData.ColumnNames {
+		var colIdentifier string
+		if table.displayName() == "" {
+			colIdentifier = col
+		} else {
+			colIdentifier = table.displayName() + "." + col
+		}
+
+		colMatchList, err := detectValues(detectors, colIdentifier, tableData.ColumnValues[i])
+		if err != nil {
+			return nil, err
+		}
+		matchList = append(matchList, colMatchList...)
+	}
+
+	return matchList, nil
+}
+
+func detectFileData(detectors []Detector, file string, matchFinder *MatchFinder) ([]ruleMatch, error) {
+	matchList, err := detectValues(detectors, file, matchFinder.DetectorValues)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range matchFinder.fieldPaths() {
+		fieldMatchList, err := detectValues(detectors, file+" "+path, matchFinder.Fields[path].DetectorValues)
+		if err != nil {
+			return nil, err
+		}
+		matchList = append(matchList, fieldMatchList...)
+	}
+
+	return matchList, nil
+}
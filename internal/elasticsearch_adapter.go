@@ -2,6 +2,7 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -101,7 +102,7 @@ func (a ElasticsearchAdapter) FetchTables() ([]table, error) {
 	return tables, nil
 }
 
-func (a ElasticsearchAdapter) FetchTableData(table table, limit int) (*tableData, error) {
+func (a ElasticsearchAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
 	es := a.DB
 
 	var r map[string]interface{}
@@ -119,6 +120,7 @@ func (a ElasticsearchAdapter) FetchTableData(table table, limit int) (*tableData
 	}
 
 	res, err := es.Search(
+		es.Search.WithContext(ctx),
 		es.Search.WithIndex(table.Name),
 		es.Search.WithBody(&buf),
 	)
@@ -140,7 +142,8 @@ func (a ElasticsearchAdapter) FetchTableData(table table, limit int) (*tableData
 
 	columnValues := make([][]string, 0)
 
-	for _, hit := range r["hits"].(map[string]interface{})["hits"].([]interface{}) {
+	hits := r["hits"].(map[string]interface{})["hits"].([]interface{})
+	for _, hit := range hits {
 		// TODO check _id
 		source := hit.(map[string]interface{})["_source"].(map[string]interface{})
 		keyMap, columnValues = scanSource(source, "", keyMap, columnValues)
@@ -151,7 +154,7 @@ func (a ElasticsearchAdapter) FetchTableData(table table, limit int) (*tableData
 		columnNames[i] = key
 	}
 
-	return &tableData{columnNames, columnValues}, nil
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: len(hits)}, nil
 }
 
 func scanSource(object map[string]interface{}, prefix string, keyMap map[string]int, columnValues [][]string) (map[string]int, [][]string) {
@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+
+// at least one letter, so numeric IDs are held to the higher threshold
+var hexString = regexp.MustCompile(`^[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*$`)
+
+// bits per character for random strings of each charset
+const base64Bits = 6.0
+const hexBits = 4.0
+
+// hashes and ciphertext are random too, but have their own rules
+var storedDataRegexes = []*regexp.Regexp{passwordHashRegex, hexDigestRegex, encryptedDataRegex}
+
+func highEntropyStrings(rule entropyRule, v string) []string {
+	for _, regex := range storedDataRegexes {
+		v = regex.ReplaceAllString(v, " ")
+	}
+
+	matches := []string{}
+	for _, candidate := range entropyCandidate.FindAllString(v, -1) {
+		if len(candidate) < rule.MinLength {
+			continue
+		}
+
+		threshold := rule.Threshold
+		if hexString.MatchString(candidate) {
+			threshold = threshold * hexBits / base64Bits
+		}
+
+		if shannonEntropy(candidate) > threshold {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+// rules out placeholders like 00000000000000000000000000000000
+func randomHex(v string) bool {
+	return shannonEntropy(v) > hexBits*0.75
+}
+
+func shannonEntropy(str string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range str {
+		counts[c]++
+	}
+
+	entropy := 0.0
+	length := float64(len(str))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func findEntropyRule(name string, rules []entropyRule) *entropyRule {
+	for i := range rules {
+		if rules[i].Name == name {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// hints match at the start or end of a word in the name, like ssn in user_ssn or key in apiKey,
+// but not inside a word, like ssn in classname
+func matchesColumnHint(col string, hints []string) bool {
+	name := columnNameWords(col)
+	for _, hint := range hints {
+		hint = columnNameWords(hint)
+		for i := 0; i+len(hint) <= len(name); i++ {
+			if name[i:i+len(hint)] != hint {
+				continue
+			}
+			end := i + len(hint)
+			if i == 0 || name[i-1] == '_' || end == len(name) || name[end] == '_' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lowercase words separated by underscores, so camelCase and other separators match the same
+func columnNameWords(col string) string {
+	var b strings.Builder
+	runes := []rune(col)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune('_')
+			continue
+		}
+		// a new word starts at an uppercase letter after a lowercase one, or at the end of an acronym, like SSNLast
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
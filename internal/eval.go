@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// a rule found for an identifier
+type evalKey struct {
+	Identifier string `json:"identifier"`
+	Rule       string `json:"rule"`
+}
+
+type evalResult struct {
+	Rule           string  `json:"rule"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+}
+
+type evalReport struct {
+	Rules          []evalResult `json:"rules"`
+	Overall        evalResult   `json:"overall"`
+	FalsePositives []evalKey    `json:"false_positives"`
+	FalseNegatives []evalKey    `json:"false_negatives"`
+}
+
+// Eval scans labeled data, like from gen-corpus, and reports precision and recall for each rule
+// matches and labels are compared by identifier and rule
+func Eval(urlStr string, truthFile string, limit int, showAll bool, only string, except string, rulesFile string, format string, output io.Writer) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("Invalid format: %s\nValid formats are json, text", format)
+	}
+
+	labels, err := loadEvalLabels(truthFile)
+	if err != nil {
+		return err
+	}
+
+	matchConfig := NewMatchConfig()
+	if rulesFile != "" {
+		err := addRulesFile(&matchConfig, rulesFile)
+		if err != nil {
+			return err
+		}
+	}
+	if except != "" {
+		err := updateRules(&matchConfig, except, true)
+		if err != nil {
+			return err
+		}
+	}
+	if only != "" {
+		err := updateRules(&matchConfig, only, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	adapter := newAdapter(urlStr, "")
+	matchList, err := adapter.Scan(ScanOpts{
+		UrlStr:      urlStr,
+		ShowAll:     showAll,
+		Limit:       limit,
+		Formatter:   discardFormatter{},
+		MatchConfig: &matchConfig,
+		Coverage:    &coverage{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// like the output, low confidence matches are only counted with --show-all
+	found := []evalKey{}
+	for _, match := range matchList {
+		if showAll || match.Confidence != "low" {
+			found = append(found, evalKey{match.Identifier, match.RuleName})
+		}
+	}
+
+	// rules that were excluded are not evaluated
+	validNames := makeValidNames(&matchConfig)
+	expected := []evalKey{}
+	for _, label := range labels {
+		if validNames[label.Rule] {
+			expected = append(expected, label)
+		}
+	}
+
+	report := newEvalReport(found, expected)
+	if format == "json" {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printEvalReport(output, report)
+	return nil
+}
+
+func loadEvalLabels(filename string) ([]evalKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var truth corpusLabels
+	err = json.Unmarshal(data, &truth)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid truth file %s: %s", filename, err)
+	}
+
+	labels := []evalKey{}
+	for _, label := range truth.Labels {
+		if label.Identifier == "" || label.Rule == "" {
+			return nil, fmt.Errorf("Invalid truth file %s: labels require an identifier and rule", filename)
+		}
+		labels = append(labels, evalKey{label.Identifier, label.Rule})
+	}
+	return labels, nil
+}
+
+// labels for files can be relative to the scanned directory, like corpus.jsonl $.user.email
+func evalMatches(identifier string, label string) bool {
+	return identifier == label || strings.HasSuffix(identifier, "/"+label)
+}
+
+func newEvalReport(found []evalKey, expected []evalKey) evalReport {
+	results := make(map[string]*evalResult)
+	result := func(rule string) *evalResult {
+		r, ok := results[rule]
+		if !ok {
+			r = &evalResult{Rule: rule}
+			results[rule] = r
+		}
+		return r
+	}
+
+	report := evalReport{FalsePositives: []evalKey{}, FalseNegatives: []evalKey{}}
+	matched := make([]bool, len(expected))
+	for _, key := range found {
+		truePositive := false
+		for i, label := range expected {
+			if key.Rule == label.Rule && evalMatches(key.Identifier, label.Identifier) {
+				matched[i] = true
+				truePositive = true
+			}
+		}
+		if truePositive {
+			result(key.Rule).TruePositives++
+		} else {
+			result(key.Rule).FalsePositives++
+			report.FalsePositives = append(report.FalsePositives, key)
+		}
+	}
+	for i, label := range expected {
+		if !matched[i] {
+			result(label.Rule).FalseNegatives++
+			report.FalseNegatives = append(report.FalseNegatives, label)
+		}
+	}
+
+	rules := make([]string, 0, len(results))
+	for rule := range results {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	report.Overall.Rule = "overall"
+	report.Rules = []evalResult{}
+	for _, rule := range rules {
+		r := results[rule]
+		r.score()
+		report.Rules = append(report.Rules, *r)
+		report.Overall.TruePositives += r.TruePositives
+		report.Overall.FalsePositives += r.FalsePositives
+		report.Overall.FalseNegatives += r.FalseNegatives
+	}
+	report.Overall.score()
+
+	sortEvalKeys(report.FalsePositives)
+	sortEvalKeys(report.FalseNegatives)
+	return report
+}
+
+// precision and recall are 1 when there is nothing to find or nothing was found
+func (r *evalResult) score() {
+	r.Precision = 1
+	if r.TruePositives+r.FalsePositives > 0 {
+		r.Precision = float64(r.TruePositives) / float64(r.TruePositives+r.FalsePositives)
+	}
+	r.Recall = 1
+	if r.TruePositives+r.FalseNegatives > 0 {
+		r.Recall = float64(r.TruePositives) / float64(r.TruePositives+r.FalseNegatives)
+	}
+	r.F1 = 0
+	if r.Precision+r.Recall > 0 {
+		r.F1 = 2 * r.Precision * r.Recall / (r.Precision + r.Recall)
+	}
+}
+
+func sortEvalKeys(keys []evalKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Identifier != keys[j].Identifier {
+			return keys[i].Identifier < keys[j].Identifier
+		}
+		return keys[i].Rule < keys[j].Rule
+	})
+}
+
+func printEvalReport(writer io.Writer, report evalReport) {
+	fmt.Fprintf(writer, "%-24s %9s %6s %6s %5s %5s %5s\n", "rule", "precision", "recall", "f1", "tp", "fp", "fn")
+	for _, r := range append(report.Rules, report.Overall) {
+		fmt.Fprintf(writer, "%-24s %9.2f %6.2f %6.2f %5d %5d %5d\n", r.Rule, r.Precision, r.Recall, r.F1, r.TruePositives, r.FalsePositives, r.FalseNegatives)
+	}
+
+	if len(report.FalsePositives) > 0 {
+		fmt.Fprintln(writer, "\nFalse positives")
+		for _, key := range report.FalsePositives {
+			fmt.Fprintf(writer, "    %s: %s\n", key.Identifier, key.Rule)
+		}
+	}
+	if len(report.FalseNegatives) > 0 {
+		fmt.Fprintln(writer, "\nFalse negatives")
+		for _, key := range report.FalseNegatives {
+			fmt.Fprintf(writer, "    %s: %s\n", key.Identifier, key.Rule)
+		}
+	}
+}
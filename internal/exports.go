@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// CSV headers of password manager exports
+// exports with more columns than these are still matched
+var passwordExportHeaders = []struct {
+	format  string
+	columns string
+}{
+	{"LastPass CSV", "url,username,password,totp,extra,name,grouping,fav"},
+	{"LastPass CSV", "url,username,password,extra,name,grouping,fav"},
+	{"1Password CSV", "title,url,username,password,otpauth,favorite,archived,tags,notes"},
+	{"Bitwarden CSV", "folder,favorite,type,name,notes,fields,reprompt,login_uri,login_username,login_password"},
+	{"Bitwarden CSV", "folder,favorite,type,name,notes,fields,login_uri,login_username,login_password"},
+	{"KeePass CSV", "group,title,username,password,url,notes"},
+	{"KeePass CSV", "account,login name,password,web site,comments"},
+	{"Chrome CSV", "name,url,username,password"},
+	{"Firefox CSV", "url,username,password,httprealm,formactionorigin,guid"},
+}
+
+var bitwardenJsonStart = regexp.MustCompile(`^\{\s*"encrypted"\s*:\s*(true|false)\s*,`)
+
+// exports are incidents even when values do not match other rules
+func passwordManagerExport(head []byte) string {
+	head = bytes.TrimLeft(head, "\ufeff \t\r\n")
+
+	if bytes.Contains(head, []byte("<KeePassFile>")) {
+		return "KeePass XML"
+	}
+	if bitwardenJsonStart.Match(head) {
+		return "Bitwarden JSON"
+	}
+
+	i := bytes.IndexByte(head, '\n')
+	if i == -1 {
+		i = len(head)
+	}
+	columns := strings.Split(strings.ToLower(strings.TrimSpace(string(head[:i]))), ",")
+	for j, column := range columns {
+		columns[j] = strings.Trim(strings.TrimSpace(column), `"`)
+	}
+	header := strings.Join(columns, ",")
+
+	for _, export := range passwordExportHeaders {
+		if header == export.columns || strings.HasPrefix(header, export.columns+",") {
+			return export.format
+		}
+	}
+	return ""
+}
@@ -4,5 +4,12 @@ type FileAdapter interface {
 	ObjectName() string
 	Init(url string) error
 	FetchFiles() ([]string, error)
+	FileSize(file string) (int64, error)
 	FindFileMatches(file string, matchFinder *MatchFinder) error
 }
+
+// accountAdapter is implemented by file adapters that scan multiple accounts,
+// so matches can be grouped by account
+type accountAdapter interface {
+	account(file string) string
+}
@@ -5,32 +5,184 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"io"
+	"os"
+	"unicode/utf8"
 
-	"github.com/h2non/filetype"
+	"github.com/jmoiron/sqlx"
 )
 
+// lines longer than this are scanned in chunks
+// so a single huge line (minified JSON, log exports) never has to fit in memory
+const chunkSize = 64 * 1024
+
+// bytes carried over from the previous chunk
+// so matches spanning a chunk boundary are still found
+const chunkOverlap = 512
+
+// rows sampled from each table of embedded databases
+const embeddedSampleSize = 10000
+
+// skipError is returned when a file is intentionally not scanned
+type skipError struct {
+	kind   string
+	reason string
+}
+
+func (e skipError) Error() string {
+	return e.reason
+}
+
 func findScannerMatches(reader io.Reader, matchFinder *MatchFinder) error {
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		v := scanner.Text()
+	bufReader := bufio.NewReaderSize(reader, chunkSize)
+
+	// state for lines longer than the buffer
+	var overlap []byte
+	var pending []byte
+	inLine := false
+
+	for {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+
 		// TODO pass archive file and line number in file
-		matchFinder.Scan(v, matchFinder.Count)
+		line, err := bufReader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			chunk := append(pending, line...)
+
+			// do not split a multibyte character
+			n := lastRuneStart(chunk)
+			pending = append([]byte(nil), chunk[n:]...)
+			chunk = chunk[:n]
+
+			matchFinder.scanChunk(string(overlap), string(chunk), matchFinder.Count)
+			overlap = tail(append(overlap, chunk...), chunkOverlap)
+			inLine = true
+			continue
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+
+		chunk := append(pending, trimNewline(line)...)
+		if err == io.EOF && len(chunk) == 0 && !inLine {
+			return nil
+		}
+
+		if len(chunk) > 0 || !inLine {
+			matchFinder.scanChunk(string(overlap), string(chunk), matchFinder.Count)
+		}
 		matchFinder.Count += 1
+
+		overlap = nil
+		pending = nil
+		inLine = false
+
+		if err == io.EOF {
+			return nil
+		}
 	}
-	return nil
+}
+
+// GeoJSON, vCard, iCalendar, and JSON lines are scanned by field
+// other text is scanned line by line
+func processText(reader *bufio.Reader, matchFinder *MatchFinder) error {
+	head, err := peekHead(reader)
+	if err != nil {
+		return err
+	}
+
+	process := findHandler(head, true)
+	if process != nil {
+		return process(reader, matchFinder)
+	}
+	return findScannerMatches(reader, matchFinder)
+}
+
+func processJsonLines(reader io.Reader, matchFinder *MatchFinder) error {
+	bufReader := bufio.NewReaderSize(reader, chunkSize)
+
+	for {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+
+		line, err := bufReader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// records too large to parse in memory are scanned as text from here on
+			rest := io.MultiReader(bytes.NewReader(append([]byte(nil), line...)), bufReader)
+			return findScannerMatches(rest, matchFinder)
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+
+		line = trimNewline(line)
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+
+		var record map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if decoder.Decode(&record) == nil {
+			matchFinder.ScanRecord(record, "$")
+		} else {
+			matchFinder.Scan(string(line), matchFinder.Count)
+		}
+		matchFinder.Count += 1
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func trimNewline(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] == '\n' {
+		b = b[:len(b)-1]
+		if len(b) > 0 && b[len(b)-1] == '\r' {
+			b = b[:len(b)-1]
+		}
+	}
+	return b
+}
+
+func lastRuneStart(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return len(b)
+			}
+			return i
+		}
+	}
+	return len(b)
+}
+
+func tail(b []byte, n int) []byte {
+	if len(b) > n {
+		b = b[len(b)-n:]
+	}
+	return append([]byte(nil), b...)
 }
 
 func processZip(file io.Reader, matchFinder *MatchFinder) error {
-	// TODO make more efficient
-	data, err := io.ReadAll(file)
+	// zip needs random access, so spool to disk instead of memory
+	tmp, err := os.CreateTemp("", "pdscan")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, file)
 	if err != nil {
 		return err
 	}
-	bytesFile := bytes.NewReader(data)
-	size := int64(bytesFile.Size())
 
-	reader, err := zip.NewReader(bytesFile, size)
+	reader, err := zip.NewReader(tmp, size)
 	if err != nil {
 		return err
 	}
@@ -44,13 +196,67 @@ func processZip(file io.Reader, matchFinder *MatchFinder) error {
 		if err != nil {
 			return err
 		}
-		defer fileReader.Close()
 
 		// TODO capture specific file in archive
 		err = processFile(fileReader, matchFinder)
+		fileReader.Close()
+
+		// skip unsupported files in archives, but scan the rest
+		var skipErr skipError
+		if errors.As(err, &skipErr) {
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// https://duckdb.org/docs/internals/storage
+func isDuckdb(head []byte) bool {
+	return len(head) >= 12 && string(head[8:12]) == "DUCK"
+}
+
+// tables are scanned by column, like schema.table.column
+func processDuckdb(file io.Reader, matchFinder *MatchFinder) error {
+	tmp, err := os.CreateTemp("", "pdscan*.duckdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = io.Copy(tmp, file)
+	if err != nil {
+		return err
+	}
+
+	db, err := sqlx.Connect("duckdb", tmp.Name()+"?access_mode=read_only")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	adapter := SqlAdapter{DB: db}
+	tables, err := adapter.FetchTables()
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+
+		data, err := adapter.FetchTableData(matchFinder.ctx, table, embeddedSampleSize)
 		if err != nil {
 			return err
 		}
+
+		for i, col := range data.ColumnNames {
+			matchFinder.scanFieldValues(table.displayName()+"."+col, data.ColumnValues[i])
+		}
 	}
 
 	return nil
@@ -62,37 +268,25 @@ func processGzip(file io.Reader, matchFinder *MatchFinder) error {
 		return err
 	}
 
-	return findScannerMatches(gz, matchFinder)
+	return processText(bufio.NewReaderSize(gz, chunkSize), matchFinder)
 }
 
 func processFile(file io.Reader, matchFinder *MatchFinder) error {
-	reader := bufio.NewReader(file)
+	reader := bufio.NewReaderSize(file, chunkSize)
 
-	// we only have to pass the file header = first 261 bytes
-	head, err := reader.Peek(261)
-	if err != nil && err != io.EOF {
+	// larger than the file type header for CSV headers
+	head, err := peekHead(reader)
+	if err != nil {
 		return err
 	}
-
-	kind, err := filetype.Match(head)
-	if err == filetype.ErrEmptyBuffer {
+	if len(head) == 0 {
 		return nil
-	} else if err != nil {
-		return err
 	}
-	// fmt.Println(kind.MIME.Value)
+	matchFinder.ScanHead(head)
 
-	// skip binary
-	// TODO better method of detection
-	if kind.MIME.Type == "video" || kind.MIME.Value == "application/x-bzip2" {
-		return nil
-		// } else if kind.MIME.Value == "application/pdf" {
-		// 	return processPdf(file)
-	} else if kind.MIME.Value == "application/zip" {
-		return processZip(reader, matchFinder)
-	} else if kind.MIME.Value == "application/gzip" {
-		return processGzip(reader, matchFinder)
+	process := findHandler(head, false)
+	if process != nil {
+		return process(reader, matchFinder)
 	}
-
 	return findScannerMatches(reader, matchFinder)
 }
@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// max findings returned in one page
+const maxFindingsLimit = 1000
+
+// finding is a match from a completed scan, so matches can be queried across scans
+type finding struct {
+	ScanId     string    `json:"scan_id"`
+	Source     string    `json:"source"`
+	Identifier string    `json:"identifier"`
+	Name       string    `json:"name"`
+	MatchType  string    `json:"match_type"`
+	Confidence string    `json:"confidence"`
+	Count      int       `json:"count"`
+	FoundAt    time.Time `json:"found_at"`
+}
+
+type findingsPage struct {
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+	Findings []finding `json:"findings"`
+}
+
+// filters, like /findings?rule=email,phone&confidence=high&since=2024-01-01T00:00:00Z&sort=-count
+type findingsQuery struct {
+	rules       []string
+	confidences []string
+	source      string
+	scanId      string
+	since       time.Time
+	sort        string
+	descending  bool
+	limit       int
+	offset      int
+}
+
+var findingsSorts = []string{"found_at", "source", "identifier", "rule", "confidence", "count"}
+
+// ordered from most to least sensitive
+var confidenceOrder = map[string]int{"critical": 0, "high": 1, "medium": 2, "low": 3}
+
+func parseFindingsQuery(values url.Values) (findingsQuery, error) {
+	q := findingsQuery{
+		source:     values.Get("source"),
+		scanId:     values.Get("scan_id"),
+		sort:       "found_at",
+		descending: true,
+		limit:      100,
+	}
+
+	if rules := values.Get("rule"); rules != "" {
+		q.rules = strings.Split(rules, ",")
+	}
+	if confidences := values.Get("confidence"); confidences != "" {
+		q.confidences = strings.Split(confidences, ",")
+		for _, confidence := range q.confidences {
+			if _, ok := confidenceOrder[confidence]; !ok {
+				return q, fmt.Errorf("Invalid confidence: %s", confidence)
+			}
+		}
+	}
+
+	if since := values.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return q, fmt.Errorf("since must be an RFC 3339 time, like 2024-01-01T00:00:00Z")
+		}
+		q.since = t
+	}
+
+	if sortStr := values.Get("sort"); sortStr != "" {
+		q.descending = strings.HasPrefix(sortStr, "-")
+		q.sort = strings.TrimPrefix(sortStr, "-")
+		if !stringInSlice(q.sort, findingsSorts) {
+			return q, fmt.Errorf("Invalid sort: %s\nValid sorts are %s", q.sort, strings.Join(findingsSorts, ", "))
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxFindingsLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxFindingsLimit)
+		}
+		q.limit = n
+	}
+	if offset := values.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("offset must not be negative")
+		}
+		q.offset = n
+	}
+
+	return q, nil
+}
+
+func (q findingsQuery) matches(f finding) bool {
+	if len(q.rules) > 0 && !stringInSlice(f.Name, q.rules) {
+		return false
+	}
+	if len(q.confidences) > 0 && !stringInSlice(f.Confidence, q.confidences) {
+		return false
+	}
+	if q.source != "" && f.Source != q.source {
+		return false
+	}
+	if q.scanId != "" && f.ScanId != q.scanId {
+		return false
+	}
+	return q.since.IsZero() || !f.FoundAt.Before(q.since)
+}
+
+func (q findingsQuery) less(a finding, b finding) bool {
+	if q.descending {
+		a, b = b, a
+	}
+	switch q.sort {
+	case "source":
+		return a.Source < b.Source
+	case "identifier":
+		return a.Identifier < b.Identifier
+	case "rule":
+		return a.Name < b.Name
+	case "confidence":
+		// -confidence lists critical first, like -count lists the most first
+		return confidenceOrder[a.Confidence] > confidenceOrder[b.Confidence]
+	case "count":
+		return a.Count < b.Count
+	default:
+		return a.FoundAt.Before(b.FoundAt)
+	}
+}
+
+// matches are only kept as long as their scans, so findings cover the last hour
+func (s *scanServer) listFindings(w http.ResponseWriter, r *http.Request) {
+	q, err := parseFindingsQuery(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	findings := []finding{}
+	s.mutex.Lock()
+	s.prune()
+	for _, job := range s.jobs {
+		if job.result.Status != "completed" {
+			continue
+		}
+		for _, match := range job.result.Matches {
+			f := finding{
+				ScanId:     job.result.Id,
+				Source:     job.result.Source,
+				Identifier: match.Identifier,
+				Name:       match.Name,
+				MatchType:  match.MatchType,
+				Confidence: match.Confidence,
+				Count:      match.Count,
+				FoundAt:    *job.result.FinishedAt,
+			}
+			if q.matches(f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+	s.mutex.Unlock()
+
+	// ties are sorted by scan and identifier, so pages are stable
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].ScanId != findings[j].ScanId {
+			return findings[i].ScanId < findings[j].ScanId
+		}
+		return findings[i].Identifier < findings[j].Identifier
+	})
+	sort.SliceStable(findings, func(i, j int) bool {
+		return q.less(findings[i], findings[j])
+	})
+
+	page := findingsPage{Total: len(findings), Limit: q.limit, Offset: q.offset, Findings: []finding{}}
+	if q.offset < len(findings) {
+		end := q.offset + q.limit
+		if end > len(findings) {
+			end = len(findings)
+		}
+		page.Findings = findings[q.offset:end]
+	}
+	writeJSON(w, http.StatusOK, page)
+}
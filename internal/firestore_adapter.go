@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// FirestoreAdapter scans Firestore collections using the REST API
+type FirestoreAdapter struct {
+	endpoint string
+	project  string
+	database string
+	client   *http.Client
+	// service accounts to impersonate, with the target last
+	impersonate []string
+}
+
+func (a *FirestoreAdapter) TableName() string {
+	return "collection"
+}
+
+func (a *FirestoreAdapter) RowName() string {
+	return "document"
+}
+
+func (a *FirestoreAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *FirestoreAdapter) Init(urlStr string) error {
+	client, err := gcpClient(context.Background(), a.impersonate)
+	if err != nil {
+		return err
+	}
+	return a.initEndpoint(urlStr, "https://firestore.googleapis.com", client)
+}
+
+func (a *FirestoreAdapter) initEndpoint(urlStr string, endpoint string, client *http.Client) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	a.project = u.Host
+	if a.project == "" {
+		return errors.New("no project specified")
+	}
+	a.database = "(default)"
+	if len(u.Path) > 1 {
+		a.database = u.Path[1:]
+	}
+	a.endpoint = endpoint
+	a.client = client
+	return nil
+}
+
+func (a *FirestoreAdapter) setImpersonate(serviceAccounts []string) {
+	a.impersonate = serviceAccounts
+}
+
+func (a *FirestoreAdapter) documentsUrl() string {
+	return a.endpoint + "/v1/projects/" + url.PathEscape(a.project) + "/databases/" + url.PathEscape(a.database) + "/documents"
+}
+
+// only root collections are listed, so subcollections are not scanned
+func (a *FirestoreAdapter) FetchTables() ([]table, error) {
+	tables := []table{}
+	pageToken := ""
+	for {
+		body := map[string]interface{}{}
+		if pageToken != "" {
+			body["pageToken"] = pageToken
+		}
+
+		var result struct {
+			CollectionIds []string `json:"collectionIds"`
+			NextPageToken string   `json:"nextPageToken"`
+		}
+		_, err := gcpRequest(context.Background(), a.client, "POST", a.documentsUrl()+":listCollectionIds", body, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, collection := range result.CollectionIds {
+			tables = append(tables, table{Name: collection})
+		}
+
+		pageToken = result.NextPageToken
+		if pageToken == "" {
+			return tables, nil
+		}
+	}
+}
+
+func (a *FirestoreAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	keyMap := make(map[string]int)
+	columnValues := make([][]string, 0)
+
+	// TODO randomize
+	count := 0
+	pageToken := ""
+	for count < limit {
+		params := url.Values{"pageSize": {strconv.Itoa(limit - count)}}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var result struct {
+			Documents []struct {
+				Fields map[string]map[string]interface{} `json:"fields"`
+			} `json:"documents"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		_, err := gcpRequest(ctx, a.client, "GET", a.documentsUrl()+"/"+url.PathEscape(table.Name)+"?"+params.Encode(), nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range result.Documents {
+			keyMap, columnValues = scanSource(firestoreFields(document.Fields), "", keyMap, columnValues)
+		}
+		count += len(result.Documents)
+
+		pageToken = result.NextPageToken
+		if pageToken == "" || len(result.Documents) == 0 {
+			break
+		}
+	}
+
+	columnNames := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: count}, nil
+}
+
+func firestoreFields(fields map[string]map[string]interface{}) map[string]interface{} {
+	document := make(map[string]interface{})
+	for name, value := range fields {
+		document[name] = firestoreValue(value)
+	}
+	return document
+}
+
+// values are typed, like {"stringValue": "value"} or {"integerValue": "123"}
+// booleans, nulls, and bytes are skipped
+func firestoreValue(value map[string]interface{}) interface{} {
+	for valueType, v := range value {
+		switch valueType {
+		case "stringValue", "integerValue", "timestampValue":
+			return v
+		case "doubleValue":
+			if f, ok := v.(float64); ok {
+				return strconv.FormatFloat(f, 'f', -1, 64)
+			}
+		case "mapValue":
+			if m, ok := v.(map[string]interface{}); ok {
+				fields := make(map[string]map[string]interface{})
+				mapFields, _ := m["fields"].(map[string]interface{})
+				for name, field := range mapFields {
+					if fieldMap, ok := field.(map[string]interface{}); ok {
+						fields[name] = fieldMap
+					}
+				}
+				return firestoreFields(fields)
+			}
+		case "arrayValue":
+			values := []interface{}{}
+			if m, ok := v.(map[string]interface{}); ok {
+				items, _ := m["values"].([]interface{})
+				for _, item := range items {
+					if itemMap, ok := item.(map[string]interface{}); ok {
+						values = append(values, firestoreValue(itemMap))
+					}
+				}
+			}
+			return values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+)
+
+// minimum length of text recovered from binary data, like the strings command
+const forensicMinText = 6
+
+// fields for deleted data in SQLite files
+const (
+	sqliteFreelistPath    = "(freelist pages)"
+	sqliteUnallocatedPath = "(unallocated space)"
+)
+
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+func isSqlite(head []byte) bool {
+	return bytes.HasPrefix(head, sqliteHeader)
+}
+
+// disk images and other binary files, which are scanned line by line unless in forensic mode
+func isBinaryFile(head []byte) bool {
+	return bytes.IndexByte(head, 0) != -1
+}
+
+// live data is scanned line by line like other files
+// in forensic mode, pages and space freed by deletes are also scanned, like for secure deletion verification
+// https://www.sqlite.org/fileformat.html
+func processSqlite(reader io.Reader, matchFinder *MatchFinder) error {
+	if !matchFinder.forensic {
+		return findScannerMatches(reader, matchFinder)
+	}
+
+	tmp, err := os.CreateTemp("", "pdscan*.sqlite3")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	err = findScannerMatches(io.TeeReader(reader, tmp), matchFinder)
+	if err != nil {
+		return err
+	}
+
+	return scanSqliteDeleted(tmp, matchFinder)
+}
+
+func scanSqliteDeleted(file *os.File, matchFinder *MatchFinder) error {
+	header := make([]byte, 100)
+	_, err := file.ReadAt(header, 0)
+	if err != nil {
+		return err
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(header[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	pageCount := int(info.Size() / int64(pageSize))
+
+	page := make([]byte, pageSize)
+	readPage := func(n int) error {
+		_, err := file.ReadAt(page, int64(n-1)*int64(pageSize))
+		return err
+	}
+
+	// trunk pages list leaf pages, and the rest of each trunk page is unused
+	freePages := make(map[int]bool)
+	leafPages := []int{}
+	trunk := int(binary.BigEndian.Uint32(header[32:36]))
+	for trunk > 0 && trunk <= pageCount && !freePages[trunk] {
+		freePages[trunk] = true
+		err = readPage(trunk)
+		if err != nil {
+			return err
+		}
+
+		leaves := int(binary.BigEndian.Uint32(page[4:8]))
+		if leaves > (pageSize-8)/4 {
+			break
+		}
+		for i := 0; i < leaves; i++ {
+			leaf := int(binary.BigEndian.Uint32(page[8+4*i:]))
+			if leaf > 0 && leaf <= pageCount && !freePages[leaf] {
+				freePages[leaf] = true
+				leafPages = append(leafPages, leaf)
+			}
+		}
+		matchFinder.scanDeleted(sqliteFreelistPath, page[8+4*leaves:])
+		trunk = int(binary.BigEndian.Uint32(page[0:4]))
+	}
+
+	sort.Ints(leafPages)
+	for _, leaf := range leafPages {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+		err = readPage(leaf)
+		if err != nil {
+			return err
+		}
+		matchFinder.scanDeleted(sqliteFreelistPath, page)
+	}
+
+	// b-tree pages have unallocated space between the cell pointers and cells, and freeblocks for deleted cells
+	for n := 1; n <= pageCount; n++ {
+		if freePages[n] {
+			continue
+		}
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+		err = readPage(n)
+		if err != nil {
+			return err
+		}
+
+		// the first page starts with the file header
+		offset := 0
+		if n == 1 {
+			offset = 100
+		}
+
+		var headerSize int
+		switch page[offset] {
+		case 0x0a, 0x0d:
+			headerSize = 8
+		case 0x02, 0x05:
+			headerSize = 12
+		default:
+			// overflow and pointer map pages
+			continue
+		}
+
+		cells := int(binary.BigEndian.Uint16(page[offset+3:]))
+		contentStart := int(binary.BigEndian.Uint16(page[offset+5:]))
+		if contentStart == 0 {
+			contentStart = 65536
+		}
+		start := offset + headerSize + 2*cells
+		if start < contentStart && contentStart <= pageSize {
+			matchFinder.scanDeleted(sqliteUnallocatedPath, page[start:contentStart])
+		}
+
+		// each freeblock starts with the offset of the next and its size, which includes those 4 bytes
+		freeblock := int(binary.BigEndian.Uint16(page[offset+1:]))
+		for i := 0; freeblock != 0 && freeblock+4 <= pageSize && i < pageSize/4; i++ {
+			size := int(binary.BigEndian.Uint16(page[freeblock+2:]))
+			end := freeblock + size
+			// corrupt
+			if size < 4 || end > pageSize {
+				break
+			}
+			matchFinder.scanDeleted(sqliteUnallocatedPath, page[freeblock+4:end])
+			freeblock = int(binary.BigEndian.Uint16(page[freeblock:]))
+		}
+	}
+
+	return nil
+}
+
+// text in deleted data is scanned as a field, so matches are reported separately from live data
+func (a *MatchFinder) scanDeleted(path string, data []byte) {
+	values := []string{}
+	scanBinaryText(bytes.NewReader(data), func(text string) error {
+		values = append(values, text)
+		return nil
+	})
+	if len(values) > 0 {
+		a.scanFieldValues(path, values)
+	}
+}
+
+// in forensic mode, text anywhere in binary files is scanned, including UTF-16 text
+// for disk images, this includes unallocated space and file slack
+func processBinaryFile(reader io.Reader, matchFinder *MatchFinder) error {
+	if !matchFinder.forensic {
+		return findScannerMatches(reader, matchFinder)
+	}
+
+	return scanBinaryText(reader, func(text string) error {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+		matchFinder.Scan(text, matchFinder.Count)
+		matchFinder.Count += 1
+		return nil
+	})
+}
+
+// finds runs of printable ASCII and UTF-16LE text, like the strings command
+func scanBinaryText(reader io.Reader, emit func(string) error) error {
+	bufReader := bufio.NewReaderSize(reader, chunkSize)
+
+	var ascii []byte
+	var wide []byte
+	// UTF-16LE characters are followed by a null byte
+	wideNull := false
+
+	var emitErr error
+	flush := func(run []byte) []byte {
+		if len(run) >= forensicMinText && emitErr == nil {
+			emitErr = emit(string(run))
+		}
+		return run[:0]
+	}
+
+	for emitErr == nil {
+		b, err := bufReader.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		printable := (b >= 0x20 && b < 0x7f) || b == '\t'
+
+		if printable {
+			ascii = append(ascii, b)
+			if len(ascii) == chunkSize {
+				ascii = flush(ascii)
+			}
+		} else {
+			ascii = flush(ascii)
+		}
+
+		if wideNull {
+			wideNull = false
+			if b == 0 {
+				if len(wide) == chunkSize {
+					wide = flush(wide)
+				}
+				continue
+			}
+			// the last character was not UTF-16
+			wide = flush(wide[:len(wide)-1])
+		}
+		if printable {
+			wide = append(wide, b)
+			wideNull = true
+		} else {
+			wide = flush(wide)
+		}
+	}
+
+	if wideNull {
+		wide = wide[:len(wide)-1]
+	}
+	flush(ascii)
+	flush(wide)
+	return emitErr
+}
@@ -13,12 +13,21 @@ import (
 type Formatter interface {
 	// PrintMatch formats and prints the match to `writer`.
 	PrintMatch(writer io.Writer, match matchInfo) error
+	// Finish is called once after the scan, for formatters that print everything at the end.
+	Finish(writer io.Writer) error
 }
 
+// version of the ndjson and coverage-json schemas in the schema directory
+// fields can be added in the same version, and it only changes when fields are removed or changed
+const schemaVersion = 1
+
 // Formatters holds available formatters
-var Formatters = map[string]Formatter{
-	"text":   TextFormatter{},
-	"ndjson": JSONFormatter{},
+var Formatters = map[string]func() Formatter{
+	"text":          func() Formatter { return TextFormatter{} },
+	"ndjson":        func() Formatter { return &JSONFormatter{} },
+	"markdown":      func() Formatter { return &MarkdownFormatter{} },
+	"template":      func() Formatter { return &TemplateFormatter{} },
+	"coverage-json": func() Formatter { return &CoverageFormatter{} },
 }
 
 // TextFormatter prints the result as human readable text.
@@ -28,11 +37,21 @@ func (f TextFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
 	var description string
 	if match.MatchType == "name" {
 		description = fmt.Sprintf("possible %s (name match)", match.DisplayName)
+	} else if match.MatchType == "comment" {
+		description = fmt.Sprintf("%s (column comment)", match.DisplayName)
+	} else if match.MatchType == "format" {
+		description = fmt.Sprintf("found %s (file format, %s)", match.DisplayName, match.Confidence)
 	} else {
 		str := pluralize(match.LineCount, match.RowStr)
 		if match.Confidence == "low" {
 			str = str + ", low confidence"
 		}
+		if len(match.Brands) > 0 {
+			str = str + ", " + cardBrandSummary(match.Brands)
+		}
+		if match.Truncated {
+			str = str + ", values truncated"
+		}
 		if match.RowStr == "key" {
 			description = fmt.Sprintf("found %s", match.DisplayName)
 		} else {
@@ -40,8 +59,13 @@ func (f TextFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
 		}
 	}
 
+	identifier := match.Identifier
+	if match.Account != "" {
+		identifier = fmt.Sprintf("%s (account %s)", identifier, match.Account)
+	}
+
 	yellow := color.New(color.FgYellow).SprintFunc()
-	fmt.Fprintf(writer, "%s %s\n", yellow(match.Identifier+":"), description)
+	fmt.Fprintf(writer, "%s %s\n", yellow(identifier+":"), description)
 
 	values := match.Values
 	if values != nil {
@@ -59,14 +83,31 @@ func (f TextFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
 	return nil
 }
 
+func (f TextFormatter) Finish(writer io.Writer) error {
+	return nil
+}
+
+// labelReporter is implemented by formatters that include scan labels
+type labelReporter interface {
+	setLabels(labels map[string]string)
+}
+
 // JSONFormatter prints the result as a JSON object.
-type JSONFormatter struct{}
+type JSONFormatter struct {
+	labels map[string]string
+}
 
 type jsonEntry struct {
-	Identifier string `json:"identifier"`
-	Name       string `json:"name"`
-	MatchType  string `json:"match_type"`
-	Confidence string `json:"confidence"`
+	SchemaVersion int               `json:"schema_version"`
+	Identifier    string            `json:"identifier"`
+	Name          string            `json:"name"`
+	MatchType     string            `json:"match_type"`
+	Confidence    string            `json:"confidence"`
+	Language      string            `json:"language,omitempty"`
+	Brands        map[string]int    `json:"brands,omitempty"`
+	Truncated     bool              `json:"truncated,omitempty"`
+	Account       string            `json:"account,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
 }
 
 type jsonEntryWithMatches struct {
@@ -76,14 +117,24 @@ type jsonEntryWithMatches struct {
 	MatchesCount int      `json:"matches_count"`
 }
 
-func (f JSONFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
+func (f *JSONFormatter) setLabels(labels map[string]string) {
+	f.labels = labels
+}
+
+func (f *JSONFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
 	encoder := json.NewEncoder(writer)
 
 	entry := jsonEntry{
-		Identifier: match.Identifier,
-		Name:       match.RuleName,
-		MatchType:  match.MatchType,
-		Confidence: match.Confidence,
+		SchemaVersion: schemaVersion,
+		Identifier:    match.Identifier,
+		Name:          match.RuleName,
+		MatchType:     match.MatchType,
+		Confidence:    match.Confidence,
+		Language:      match.Language,
+		Brands:        match.Brands,
+		Truncated:     match.Truncated,
+		Account:       match.Account,
+		Labels:        f.labels,
 	}
 
 	values := match.Values
@@ -97,3 +148,7 @@ func (f JSONFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
 		return encoder.Encode(entry)
 	}
 }
+
+func (f *JSONFormatter) Finish(writer io.Writer) error {
+	return nil
+}
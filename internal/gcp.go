@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+const iamCredentialsEndpoint = "https://iamcredentials.googleapis.com"
+
+// impersonated tokens are short-lived and refreshed as needed
+const gcpTokenLifetime = time.Hour
+
+// gcpAdapter is implemented by adapters for Google Cloud,
+// so scans can run as a service account without a key file
+type gcpAdapter interface {
+	setImpersonate(serviceAccounts []string)
+}
+
+// service accounts, like scanner@project.iam.gserviceaccount.com,
+// with delegates first and the service account to impersonate last, like gcloud
+func parseServiceAccounts(value string) ([]string, error) {
+	serviceAccounts := []string{}
+	for _, serviceAccount := range strings.Split(value, ",") {
+		serviceAccount = strings.TrimSpace(serviceAccount)
+		if !strings.Contains(serviceAccount, "@") {
+			return nil, fmt.Errorf("Invalid service account: %s", serviceAccount)
+		}
+		serviceAccounts = append(serviceAccounts, serviceAccount)
+	}
+	return serviceAccounts, nil
+}
+
+// uses Application Default Credentials, so gcloud auth, Workload Identity on GKE,
+// and workload identity federation work without key files
+func gcpClient(ctx context.Context, impersonate []string) (*http.Client, error) {
+	creds, err := google.FindDefaultCredentials(ctx, gcpScope)
+	if err != nil {
+		return nil, fmt.Errorf("no Google Cloud credentials found, run gcloud auth application-default login or use Workload Identity (%s)", err)
+	}
+
+	tokenSource := creds.TokenSource
+	if len(impersonate) > 0 {
+		tokenSource = oauth2.ReuseTokenSource(nil, &impersonatedTokenSource{
+			client:          oauth2.NewClient(ctx, creds.TokenSource),
+			endpoint:        iamCredentialsEndpoint,
+			serviceAccounts: impersonate,
+		})
+	}
+	return oauth2.NewClient(ctx, tokenSource), nil
+}
+
+type impersonatedTokenSource struct {
+	client          *http.Client
+	endpoint        string
+	serviceAccounts []string
+}
+
+// https://cloud.google.com/iam/docs/reference/credentials/rest/v1/projects.serviceAccounts/generateAccessToken
+func (s *impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	target := s.serviceAccounts[len(s.serviceAccounts)-1]
+	delegates := []string{}
+	for _, serviceAccount := range s.serviceAccounts[:len(s.serviceAccounts)-1] {
+		delegates = append(delegates, "projects/-/serviceAccounts/"+serviceAccount)
+	}
+
+	body := map[string]interface{}{
+		"scope":     []string{gcpScope},
+		"lifetime":  fmt.Sprintf("%ds", int(gcpTokenLifetime.Seconds())),
+		"delegates": delegates,
+	}
+	var result struct {
+		AccessToken string    `json:"accessToken"`
+		ExpireTime  time.Time `json:"expireTime"`
+	}
+	_, err := gcpRequest(context.Background(), s.client, "POST", s.endpoint+"/v1/projects/-/serviceAccounts/"+url.PathEscape(target)+":generateAccessToken", body, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Error impersonating %s: %s", target, err)
+	}
+	return &oauth2.Token{AccessToken: result.AccessToken, TokenType: "Bearer", Expiry: result.ExpireTime}, nil
+}
+
+// decodes JSON responses into result, or returns the response to read if result is nil
+func gcpRequest(ctx context.Context, client *http.Client, method string, urlStr string, body interface{}, result interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return nil, fmt.Errorf("[%s] %s", resp.Status, e.Error.Message)
+	}
+
+	if result == nil {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+	return resp, nil
+}
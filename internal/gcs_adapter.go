@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GcsAdapter scans Google Cloud Storage objects using the JSON API
+type GcsAdapter struct {
+	url            string
+	endpoint       string
+	client         *http.Client
+	modifiedFilter modifiedFilter
+	// service accounts to impersonate, with the target last
+	impersonate []string
+	// sizes from listing objects, for the time budget
+	sizes map[string]int64
+}
+
+func (a *GcsAdapter) ObjectName() string {
+	return "object"
+}
+
+func (a *GcsAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanFiles(a, scanOpts)
+}
+
+func (a *GcsAdapter) Init(url string) error {
+	client, err := gcpClient(context.Background(), a.impersonate)
+	if err != nil {
+		return err
+	}
+	return a.initEndpoint(url, "https://storage.googleapis.com", client)
+}
+
+func (a *GcsAdapter) initEndpoint(url string, endpoint string, client *http.Client) error {
+	a.url = url
+	a.endpoint = endpoint
+	a.client = client
+	a.sizes = make(map[string]int64)
+	return nil
+}
+
+func (a *GcsAdapter) setImpersonate(serviceAccounts []string) {
+	a.impersonate = serviceAccounts
+}
+
+func (a *GcsAdapter) setModifiedFilter(filter modifiedFilter) {
+	a.modifiedFilter = filter
+}
+
+func (a *GcsAdapter) listedSize(file string) int64 {
+	return a.sizes[file]
+}
+
+// object names can contain characters like ? and #, so split the URL instead of parsing it
+func gcsObject(file string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(file, "gs://"), "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func (a *GcsAdapter) objectUrl(file string) string {
+	bucket, name := gcsObject(file)
+	return a.endpoint + "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(name)
+}
+
+func (a *GcsAdapter) FetchFiles() ([]string, error) {
+	if !strings.HasSuffix(a.url, "/") {
+		return []string{a.url}, nil
+	}
+
+	bucket, prefix := gcsObject(a.url)
+	var objects []modifiedObject
+	pageToken := ""
+	for {
+		params := url.Values{"prefix": {prefix}}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var result struct {
+			Items []struct {
+				Name    string    `json:"name"`
+				Size    string    `json:"size"`
+				Updated time.Time `json:"updated"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		_, err := gcpRequest(context.Background(), a.client, "GET", a.endpoint+"/storage/v1/b/"+url.PathEscape(bucket)+"/o?"+params.Encode(), nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			file := "gs://" + bucket + "/" + item.Name
+			objects = append(objects, modifiedObject{file, item.Updated})
+			a.sizes[file], _ = strconv.ParseInt(item.Size, 10, 64)
+		}
+
+		pageToken = result.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	return a.modifiedFilter.apply(objects), nil
+}
+
+func (a *GcsAdapter) FileSize(file string) (int64, error) {
+	var result struct {
+		Size string `json:"size"`
+	}
+	_, err := gcpRequest(context.Background(), a.client, "GET", a.objectUrl(file), nil, &result)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(result.Size, 10, 64)
+}
+
+func (a *GcsAdapter) FindFileMatches(file string, matchFinder *MatchFinder) error {
+	resp, err := gcpRequest(matchFinder.ctx, a.client, "GET", a.objectUrl(file)+"?alt=media", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return processFile(bufio.NewReader(resp.Body), matchFinder)
+}
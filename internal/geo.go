@@ -0,0 +1,327 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// max size of a shapefile record
+const shapeRecordLimit = 64 * 1024 * 1024
+
+var geoJsonType = regexp.MustCompile(`"type"\s*:\s*"Feature(Collection)?"`)
+
+// checks if the first object is a GeoJSON feature or feature collection
+func isGeoJson(head []byte) bool {
+	head = bytes.TrimSpace(head)
+	return len(head) > 0 && head[0] == '{' && geoJsonType.Match(head)
+}
+
+// features are decoded one at a time so large collections never have to fit in memory
+// also supports newline delimited features
+func processGeoJson(reader io.Reader, matchFinder *MatchFinder) error {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if token != json.Delim('{') {
+			return fmt.Errorf("Invalid GeoJSON")
+		}
+
+		object := make(map[string]interface{})
+		for decoder.More() {
+			if matchFinder.expired() {
+				return errTimeBudget
+			}
+
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := token.(string)
+
+			if key == "features" {
+				err = processGeoJsonFeatures(decoder, matchFinder)
+			} else {
+				var value interface{}
+				err = decoder.Decode(&value)
+				object[key] = value
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// closing brace
+		_, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		if object["type"] == "Feature" {
+			matchFinder.scanFeature(object, "$")
+			matchFinder.Count += 1
+		} else if object["type"] != "FeatureCollection" {
+			matchFinder.ScanRecord(object, "$")
+			matchFinder.Count += 1
+		}
+	}
+}
+
+func processGeoJsonFeatures(decoder *json.Decoder, matchFinder *MatchFinder) error {
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('[') {
+		return err
+	}
+
+	for decoder.More() {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+
+		var feature map[string]interface{}
+		err := decoder.Decode(&feature)
+		if err != nil {
+			return err
+		}
+		matchFinder.scanFeature(feature, "$.features[*]")
+		matchFinder.Count += 1
+	}
+
+	// closing bracket
+	_, err = decoder.Token()
+	return err
+}
+
+// properties are scanned like JSON lines
+func (a *MatchFinder) scanFeature(feature map[string]interface{}, path string) {
+	if geometry, ok := feature["geometry"].(map[string]interface{}); ok {
+		a.scanGeometry(geometry, path+".geometry")
+	}
+	if properties, ok := feature["properties"].(map[string]interface{}); ok {
+		a.ScanRecord(properties, path+".properties")
+	}
+}
+
+// points and lines can locate people, like addresses and GPS tracks
+// polygons are usually areas, like zip codes and regions, so are not reported
+func (a *MatchFinder) scanGeometry(geometry map[string]interface{}, path string) {
+	coordinates := geometry["coordinates"]
+
+	var positions []interface{}
+	switch geometry["type"] {
+	case "Point":
+		positions = []interface{}{coordinates}
+	case "MultiPoint", "LineString":
+		positions, _ = coordinates.([]interface{})
+	case "MultiLineString":
+		lines, _ := coordinates.([]interface{})
+		for _, line := range lines {
+			linePositions, _ := line.([]interface{})
+			positions = append(positions, linePositions...)
+		}
+	case "GeometryCollection":
+		geometries, _ := geometry["geometries"].([]interface{})
+		for _, nested := range geometries {
+			if nestedGeometry, ok := nested.(map[string]interface{}); ok {
+				a.scanGeometry(nestedGeometry, path+".geometries[*]")
+			}
+		}
+		return
+	}
+
+	values := []string{}
+	for _, position := range positions {
+		numbers, _ := position.([]interface{})
+		if len(numbers) < 2 {
+			continue
+		}
+		x, xOk := numbers[0].(json.Number)
+		y, yOk := numbers[1].(json.Number)
+		if xOk && yOk {
+			values = append(values, x.String()+" "+y.String())
+		}
+	}
+
+	if len(values) > 0 {
+		a.scanCoordinates(path+".coordinates", values)
+	}
+}
+
+// https://www.esri.com/content/dam/esrisites/sitecore-archive/Files/Pdfs/library/whitepapers/pdfs/shapefile.pdf
+// index files have the same header, but the first record is an offset of 50 instead of record number 1
+func isShapefile(head []byte) bool {
+	return len(head) >= 104 && binary.BigEndian.Uint32(head[0:4]) == 9994 && binary.LittleEndian.Uint32(head[28:32]) == 1000 && binary.BigEndian.Uint32(head[100:104]) == 1
+}
+
+// coordinates of points and lines are scanned as geometry
+// attributes are in a separate dBase file
+func processShapefile(reader io.Reader, matchFinder *MatchFinder) error {
+	_, err := io.CopyN(io.Discard, reader, 100)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	for {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+
+		_, err := io.ReadFull(reader, header)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// length is in 16-bit words
+		length := int64(binary.BigEndian.Uint32(header[4:8])) * 2
+		if length > shapeRecordLimit {
+			return fmt.Errorf("Invalid shapefile record")
+		}
+
+		content := make([]byte, length)
+		_, err = io.ReadFull(reader, content)
+		if err != nil {
+			return err
+		}
+
+		values := shapePoints(content)
+		if len(values) > 0 {
+			matchFinder.scanCoordinates("geometry", values)
+		}
+		matchFinder.Count += 1
+	}
+}
+
+func shapePoints(content []byte) []string {
+	if len(content) < 4 {
+		return nil
+	}
+
+	var offset int
+	var count int
+	// Z and M variants have the same layout for x and y
+	switch binary.LittleEndian.Uint32(content[0:4]) {
+	case 1, 11, 21: // point
+		offset = 4
+		count = 1
+	case 8, 18, 28: // multipoint
+		if len(content) < 40 {
+			return nil
+		}
+		offset = 40
+		count = int(binary.LittleEndian.Uint32(content[36:40]))
+	case 3, 13, 23: // polyline
+		if len(content) < 44 {
+			return nil
+		}
+		parts := int(binary.LittleEndian.Uint32(content[36:40]))
+		offset = 44 + 4*parts
+		count = int(binary.LittleEndian.Uint32(content[40:44]))
+	default:
+		return nil
+	}
+
+	values := []string{}
+	for i := 0; i < count && offset+16 <= len(content) && len(values) < fieldValueLimit; i++ {
+		x := math.Float64frombits(binary.LittleEndian.Uint64(content[offset : offset+8]))
+		y := math.Float64frombits(binary.LittleEndian.Uint64(content[offset+8 : offset+16]))
+		values = append(values, strconv.FormatFloat(x, 'f', -1, 64)+" "+strconv.FormatFloat(y, 'f', -1, 64))
+		offset += 16
+	}
+	return values
+}
+
+// dBase files store shapefile attributes
+// http://www.dbase.com/Knowledgebase/INT/db7_file_fmt.htm
+func isDbf(head []byte) bool {
+	if len(head) < 64 || (head[0] != 0x03 && head[0] != 0x83 && head[0] != 0x8B) {
+		return false
+	}
+	month := head[2]
+	day := head[3]
+	headerLength := binary.LittleEndian.Uint16(head[8:10])
+	recordLength := binary.LittleEndian.Uint16(head[10:12])
+	return month >= 1 && month <= 12 && day >= 1 && day <= 31 && headerLength > 32 && (headerLength-1)%32 == 0 && recordLength > 0 && strings.IndexByte("CNFLDM", head[43]) != -1
+}
+
+// attributes are scanned by column, like embedded databases
+func processDbf(reader io.Reader, matchFinder *MatchFinder) error {
+	header := make([]byte, 32)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return err
+	}
+	headerLength := int(binary.LittleEndian.Uint16(header[8:10]))
+	recordLength := int(binary.LittleEndian.Uint16(header[10:12]))
+
+	descriptors := make([]byte, headerLength-32)
+	_, err = io.ReadFull(reader, descriptors)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	var lengths []int
+	for i := 0; i+32 <= len(descriptors) && descriptors[i] != 0x0D; i += 32 {
+		name := descriptors[i : i+11]
+		if n := bytes.IndexByte(name, 0); n != -1 {
+			name = name[:n]
+		}
+		names = append(names, string(name))
+		lengths = append(lengths, int(descriptors[i+16]))
+	}
+
+	values := make([][]string, len(names))
+	record := make([]byte, recordLength)
+	for rows := 0; rows < embeddedSampleSize; rows++ {
+		if matchFinder.expired() {
+			return errTimeBudget
+		}
+
+		_, err := io.ReadFull(reader, record)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		// end of file marker
+		if record[0] == 0x1A {
+			break
+		}
+		// deleted
+		if record[0] == '*' {
+			continue
+		}
+
+		offset := 1
+		for i, length := range lengths {
+			if offset+length > len(record) {
+				break
+			}
+			values[i] = append(values[i], strings.TrimSpace(string(record[offset:offset+length])))
+			offset += length
+		}
+	}
+
+	for i, name := range names {
+		matchFinder.scanFieldValues(name, values[i])
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/h2non/filetype"
+	"github.com/jcschmidt31/pdscan/handler"
+)
+
+// fileHandler scans a content type, detected from the start of the file
+type fileHandler struct {
+	contentType string
+	detect      func(head []byte) bool
+	// text formats are also detected in compressed files
+	text    bool
+	process func(reader io.Reader, matchFinder *MatchFinder) error
+}
+
+// bytes needed to detect the file type
+const fileTypeHeaderSize = 261
+
+// checked in order, and files that match none are scanned line by line
+var fileHandlers []fileHandler
+
+// set in init since archive handlers process the files inside with the same handlers
+func init() {
+	fileHandlers = []fileHandler{
+		// TODO better method of detection
+		{contentType: "video/*", detect: isVideo, process: skipFile(skipBinary, "binary")},
+		{contentType: "application/x-bzip2", detect: isMime("application/x-bzip2"), process: skipFile(skipUnsupportedType, "unsupported type application/x-bzip2")},
+		{contentType: "application/zip", detect: isMime("application/zip"), process: processZip},
+		{contentType: "application/gzip", detect: isMime("application/gzip"), process: processGzip},
+		{contentType: "application/x-duckdb", detect: isDuckdb, process: processDuckdb},
+		{contentType: "application/x-shapefile", detect: isShapefile, process: processShapefile},
+		{contentType: "application/x-dbf", detect: isDbf, process: processDbf},
+		{contentType: "application/geo+json", detect: isGeoJson, text: true, process: processGeoJson},
+		{contentType: "text/vcard", detect: isContentLines, text: true, process: processContentLines},
+		{contentType: "application/x-ndjson", detect: isJsonLines, text: true, process: processJsonLines},
+		{contentType: "application/vnd.sqlite3", detect: isSqlite, process: processSqlite},
+		{contentType: "application/octet-stream", detect: isBinaryFile, process: processBinaryFile},
+	}
+}
+
+func isVideo(head []byte) bool {
+	kind, err := filetype.Match(fileTypeHeader(head))
+	return err == nil && kind.MIME.Type == "video"
+}
+
+func isMime(mime string) func(head []byte) bool {
+	return func(head []byte) bool {
+		kind, err := filetype.Match(fileTypeHeader(head))
+		return err == nil && kind.MIME.Value == mime
+	}
+}
+
+// only the header is passed, so Office files are detected as zip
+func fileTypeHeader(head []byte) []byte {
+	if len(head) > fileTypeHeaderSize {
+		return head[:fileTypeHeaderSize]
+	}
+	return head
+}
+
+func skipFile(kind string, reason string) func(reader io.Reader, matchFinder *MatchFinder) error {
+	return func(reader io.Reader, matchFinder *MatchFinder) error {
+		return skipError{kind, reason}
+	}
+}
+
+// checks if the first line is a JSON object
+func isJsonLines(head []byte) bool {
+	i := bytes.IndexByte(head, '\n')
+	if i == -1 {
+		// the line may continue past the start
+		if len(head) == chunkSize {
+			return false
+		}
+		i = len(head)
+	}
+
+	line := bytes.TrimSpace(head[:i])
+	return len(line) > 0 && line[0] == '{' && json.Valid(line)
+}
+
+// detects the content type, or returns an empty string for text scanned line by line
+// formats registered by programs that embed pdscan are detected first
+func detectContentType(head []byte, textOnly bool) string {
+	for _, h := range handler.Handlers() {
+		if h.Detect != nil && h.Detect(head) {
+			return h.ContentType
+		}
+	}
+
+	for _, h := range fileHandlers {
+		if (h.text || !textOnly) && h.detect(head) {
+			return h.contentType
+		}
+	}
+	return ""
+}
+
+// registered handlers replace built-in ones for the same content type
+func findHandler(head []byte, textOnly bool) func(reader io.Reader, matchFinder *MatchFinder) error {
+	contentType := detectContentType(head, textOnly)
+	if contentType == "" {
+		return nil
+	}
+
+	if h, ok := handler.Lookup(contentType); ok {
+		process := h.Process
+		return func(reader io.Reader, matchFinder *MatchFinder) error {
+			return process(reader, handlerScanner{matchFinder})
+		}
+	}
+
+	for _, h := range fileHandlers {
+		if h.contentType == contentType {
+			return h.process
+		}
+	}
+	return nil
+}
+
+// handlerScanner passes values from registered handlers to the match finder
+// each line or record counts as a line
+type handlerScanner struct {
+	matchFinder *MatchFinder
+}
+
+func (s handlerScanner) Line(text string) {
+	s.matchFinder.Scan(text, s.matchFinder.Count)
+	s.matchFinder.Count += 1
+}
+
+func (s handlerScanner) Record(record map[string]interface{}) {
+	s.matchFinder.ScanRecord(record, "$")
+	s.matchFinder.Count += 1
+}
+
+// peeks at the start of a file, without consuming it
+func peekHead(reader *bufio.Reader) ([]byte, error) {
+	head, err := reader.Peek(chunkSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	return head, nil
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// healthcheck records the result of the last scan, so monitors and probes can check scheduled scans ran
+type healthcheck struct {
+	Source       string            `json:"source"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	StartedAt    time.Time         `json:"started_at"`
+	FinishedAt   time.Time         `json:"finished_at"`
+	Success      bool              `json:"success"`
+	Error        string            `json:"error,omitempty"`
+	MatchesCount int               `json:"matches_count"`
+}
+
+func writeHealthcheck(filename string, source string, labels map[string]string, startedAt time.Time, matchesCount int, scanErr error) error {
+	h := healthcheck{
+		Source:       redactUrl(source),
+		Labels:       labels,
+		StartedAt:    startedAt.UTC(),
+		FinishedAt:   time.Now().UTC(),
+		Success:      scanErr == nil,
+		MatchesCount: matchesCount,
+	}
+	if scanErr != nil {
+		h.Error = scanErr.Error()
+		h.MatchesCount = 0
+	}
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, append(data, '\n'))
+}
+
+// Status reports the last scan from a healthcheck file
+// it fails if the scan failed or finished longer than maxAge ago, if set, for liveness probes
+func Status(filename string, maxAge string, output io.Writer) error {
+	var maxAgeDuration time.Duration
+	if maxAge != "" {
+		duration, err := time.ParseDuration(maxAge)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("Invalid max age: %s", maxAge)
+		}
+		maxAgeDuration = duration
+	}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("No scan has finished (%s not found)", filename)
+	} else if err != nil {
+		return err
+	}
+
+	var h healthcheck
+	err = json.Unmarshal(data, &h)
+	if err != nil {
+		return fmt.Errorf("Invalid healthcheck file %s: %s", filename, err)
+	}
+
+	age := time.Since(h.FinishedAt).Round(time.Second)
+	if h.Success {
+		fmt.Fprintf(output, "Last scan of %s succeeded %s ago (%s)\n", h.Source, age, pluralize(h.MatchesCount, "match"))
+	} else {
+		fmt.Fprintf(output, "Last scan of %s failed %s ago: %s\n", h.Source, age, h.Error)
+	}
+
+	if !h.Success {
+		return errors.New("Last scan failed")
+	}
+	if maxAgeDuration > 0 && age > maxAgeDuration {
+		return fmt.Errorf("Last scan is older than %s", maxAge)
+	}
+	return nil
+}
@@ -2,9 +2,12 @@ package internal
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -12,10 +15,21 @@ type ruleMatch struct {
 	RuleName    string
 	DisplayName string
 	Confidence  string
+	Source      string
 	Identifier  string
 	MatchedData []string
 	MatchType   string
 	LineCount   int
+	// dominant language of free text, if detected
+	Language string
+	// unique card numbers for each brand, for credit card matches
+	Brands map[string]int
+	// values were truncated at the column budget, so later data was not checked
+	Truncated bool
+	// cloud account of the object, when scanning multiple accounts
+	Account string
+	// rows checked, if confidence is from the share of rows with matches
+	rowCount int
 }
 
 type matchInfo struct {
@@ -45,6 +59,16 @@ func stringInSlice(a string, list []string) bool {
 	return false
 }
 
+// returns -1 if not found
+func indexOf(list []string, a string) int {
+	for i, b := range list {
+		if b == a {
+			return i
+		}
+	}
+	return -1
+}
+
 func matchNameRule(name string, rules []nameRule) nameRule {
 	for _, rule := range rules {
 		if stringInSlice(name, rule.ColumnNames) {
@@ -70,16 +94,55 @@ func pluralize(count int, singular string) string {
 	return fmt.Sprintf("%d %s", count, singular)
 }
 
-func printMatchList(formatter Formatter, matchList []ruleMatch, showData bool, showAll bool, rowStr string) error {
+// removes the password from connection URIs
+func redactUrl(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil || u.User == nil {
+		return urlStr
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB"}
+
+// parseSize parses sizes like 500MB (powers of 1024)
+func parseSize(str string) (int64, error) {
+	orig := str
+	str = strings.ToUpper(strings.TrimSpace(str))
+	multiplier := int64(1)
+	for i := len(sizeUnits) - 1; i >= 0; i-- {
+		if strings.HasSuffix(str, sizeUnits[i]) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, sizeUnits[i]))
+			multiplier = int64(1) << (10 * i)
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("Invalid size: %s", orig)
+	}
+	return value * multiplier, nil
+}
+
+func formatSize(size int64) string {
+	i := 0
+	for i < len(sizeUnits)-1 && size >= 1024 && size%1024 == 0 {
+		size /= 1024
+		i++
+	}
+	return fmt.Sprintf("%d%s", size, sizeUnits[i])
+}
+
+func printMatchList(formatter Formatter, matchList []ruleMatch, showData bool, showAll bool, maxValues int, redactor *redactor, rowStr string) error {
 	for _, match := range matchList {
 		if showAll || match.Confidence != "low" {
 			var values []string
 			if showData {
-				values = unique(match.MatchedData)
-				if len(values) > 50 {
-					values = values[0:50]
-				}
-				sort.Strings(values)
+				values = matchValues(match, maxValues, redactor)
 			}
 
 			err := formatter.PrintMatch(os.Stdout, matchInfo{match, rowStr, values})
@@ -91,6 +154,36 @@ func printMatchList(formatter Formatter, matchList []ruleMatch, showData bool, s
 	return nil
 }
 
+// unique values to show, redacted if a redactor is given
+func matchValues(match ruleMatch, maxValues int, redactor *redactor) []string {
+	values := unique(match.MatchedData)
+	if redactor != nil {
+		for i, value := range values {
+			values[i] = redactor.redact(match.RuleName, value)
+		}
+		// different values can have the same redaction
+		values = unique(values)
+	}
+	if len(values) > maxValues {
+		values = values[0:maxValues]
+	}
+	sort.Strings(values)
+	return values
+}
+
+// sets the table or file the matches came from
+func setSource(matchList []ruleMatch, source string) {
+	for i := range matchList {
+		matchList[i].Source = source
+	}
+}
+
+func setAccount(matchList []ruleMatch, account string) {
+	for i := range matchList {
+		matchList[i].Account = account
+	}
+}
+
 func showLowConfidenceMatchHelp(matchList []ruleMatch) {
 	lowConfidenceMatches := []ruleMatch{}
 	for _, match := range matchList {
@@ -102,3 +195,50 @@ func showLowConfidenceMatchHelp(matchList []ruleMatch) {
 		fmt.Fprintln(os.Stderr, "Also found "+pluralize(len(lowConfidenceMatches), "low confidence match")+". Use --show-all to view them")
 	}
 }
+
+// writes to a temporary file first so readers never see a partial file
+func writeFileAtomic(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".pdscan")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// temporary files are only readable by the owner
+	err = tmp.Chmod(0644)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// used by metrics
+var reservedLabels = []string{"source", "rule", "confidence"}
+
+// labels are key=value pairs, like env=prod
+// names follow Prometheus rules so they can be used in metrics
+func parseLabels(values []string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || !labelName.MatchString(parts[0]) || stringInSlice(parts[0], reservedLabels) {
+			return nil, fmt.Errorf("Invalid label: %s", value)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// HubspotAdapter scans contacts with the HubSpot CRM API
+type HubspotAdapter struct {
+	RestAdapter
+}
+
+func (a *HubspotAdapter) marketingPlatform() {}
+
+func (a *HubspotAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *HubspotAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	token, ok := u.User.Password()
+	if !ok {
+		token = os.Getenv("HUBSPOT_TOKEN")
+	}
+	if token == "" {
+		return errors.New("no access token specified")
+	}
+
+	return a.initEndpoint("https://api.hubapi.com", token)
+}
+
+// only a few properties are returned by default, so request all custom and standard properties
+// internal properties start with hs_, like hs_object_id
+func (a *HubspotAdapter) initEndpoint(endpoint string, token string) error {
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "bearer"
+	config.Auth.Token = token
+	config.Resources = []restResource{
+		{Name: "contacts", Path: "/crm/v3/objects/contacts", Items: "results", Fields: []string{"properties"}, Pagination: restPagination{Type: "cursor", Param: "after", SizeParam: "limit", Size: 100, Next: "paging.next.after"}},
+	}
+	err := a.init(config)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	_, err = a.client.get(context.Background(), endpoint+"/crm/v3/properties/contacts", &result)
+	if err != nil {
+		return err
+	}
+	records, err := restRecords(result, "results")
+	if err != nil {
+		return err
+	}
+
+	properties := []string{}
+	for _, record := range records {
+		name, ok := record["name"].(string)
+		if ok && !strings.HasPrefix(name, "hs_") {
+			properties = append(properties, name)
+		}
+	}
+	config.Resources[0].Params = map[string]string{"properties": strings.Join(properties, ",")}
+	return nil
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ignoreList suppresses known-safe values, like test fixtures, from reports
+type ignoreList struct {
+	Values   []string      `yaml:"values"`
+	Patterns []string      `yaml:"patterns"`
+	Matches  []ignoreMatch `yaml:"matches"`
+
+	valueSet map[string]bool
+	regexes  []*regexp.Regexp
+}
+
+// days before expiry to warn, so ignored matches can be reviewed
+const ignoreExpiryWarningDays = 14
+
+// identifiers support wildcards and an empty rule ignores all rules
+// matches stop being ignored on the expiry date, if set, so accepted risks are revisited
+type ignoreMatch struct {
+	Identifier string `yaml:"identifier"`
+	Rule       string `yaml:"rule"`
+	Owner      string `yaml:"owner"`
+	Expires    string `yaml:"expires"`
+
+	expiresAt time.Time
+}
+
+func loadIgnoreList(filename string) (*ignoreList, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var l ignoreList
+	err = yaml.Unmarshal(data, &l)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ignore file %s: %s", filename, err)
+	}
+
+	l.valueSet = make(map[string]bool)
+	for _, value := range l.Values {
+		l.valueSet[value] = true
+	}
+
+	for _, pattern := range l.Patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ignore pattern %s: %s", pattern, err)
+		}
+		l.regexes = append(l.regexes, regex)
+	}
+
+	for i, match := range l.Matches {
+		if match.Identifier == "" {
+			return nil, fmt.Errorf("Invalid ignore file %s: matches require an identifier", filename)
+		}
+		if match.Expires != "" {
+			expiresAt, err := time.ParseInLocation("2006-01-02", match.Expires, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid ignore file %s: invalid expiry date %s", filename, match.Expires)
+			}
+			l.Matches[i].expiresAt = expiresAt
+		}
+	}
+
+	return &l, nil
+}
+
+// values of a match can be whole cells or lines, so also check the parts the rule matched
+func (l *ignoreList) ignoreMatchedValue(rule *regexRule, value string) bool {
+	if l.ignoreValue(value) {
+		return true
+	}
+	if rule == nil {
+		return false
+	}
+
+	parts := rule.findAll(value)
+	if len(parts) == 0 {
+		return false
+	}
+	for _, part := range parts {
+		if !l.ignoreValue(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *ignoreList) ignoreValue(value string) bool {
+	if l.valueSet[value] {
+		return true
+	}
+	for _, regex := range l.regexes {
+		if regex.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m ignoreMatch) expired(now time.Time) bool {
+	return !m.expiresAt.IsZero() && !now.Before(m.expiresAt)
+}
+
+func (m ignoreMatch) description() string {
+	description := m.Identifier
+	if m.Rule != "" {
+		description += " (" + m.Rule + ")"
+	}
+	if m.Owner != "" {
+		description += ", owned by " + m.Owner + ","
+	}
+	return description
+}
+
+// warns about matches that are expired or expire soon
+func (l *ignoreList) warnExpiry(writer io.Writer, now time.Time) {
+	warnAt := now.AddDate(0, 0, ignoreExpiryWarningDays)
+	for _, m := range l.Matches {
+		if m.expired(now) {
+			fmt.Fprintf(writer, "Ignore for %s expired on %s and no longer applies\n", m.description(), m.Expires)
+		} else if m.expired(warnAt) {
+			fmt.Fprintf(writer, "Ignore for %s expires on %s\n", m.description(), m.Expires)
+		}
+	}
+}
+
+// warns about matches for rules that don't exist, like typos
+func (l *ignoreList) warnUnknownRules(writer io.Writer, validNames map[string]bool) {
+	for _, m := range l.Matches {
+		if m.Rule != "" && !validNames[m.Rule] {
+			fmt.Fprintf(writer, "Unknown rule in ignore file for %s: %s\n", m.Identifier, m.Rule)
+		}
+	}
+}
+
+func (l *ignoreList) ignoreIdentifier(match ruleMatch) bool {
+	now := time.Now()
+	for _, m := range l.Matches {
+		if m.Rule != "" && m.Rule != match.RuleName {
+			continue
+		}
+		if m.expired(now) {
+			continue
+		}
+		matched, err := path.Match(m.Identifier, match.Identifier)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// removes ignored values from value matches
+// and drops matches with only ignored values
+func (l *ignoreList) filter(matchList []ruleMatch, matchConfig *MatchConfig) []ruleMatch {
+	filtered := []ruleMatch{}
+	for _, match := range matchList {
+		if l.ignoreIdentifier(match) {
+			continue
+		}
+
+		var rule *regexRule
+		if matchConfig != nil {
+			rule = findRegexRule(match.RuleName, matchConfig.RegexRules)
+		}
+		match, ok := filterValues(match, func(v string) bool { return !l.ignoreMatchedValue(rule, v) })
+		if ok {
+			filtered = append(filtered, match)
+		}
+	}
+	return filtered
+}
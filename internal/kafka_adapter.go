@@ -0,0 +1,296 @@
+package internal
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaAdapter struct {
+	broker         string
+	topics         string
+	schemaRegistry string
+	codecs         map[int]*goavro.Codec
+}
+
+func (a *KafkaAdapter) TableName() string {
+	return "topic"
+}
+
+func (a *KafkaAdapter) RowName() string {
+	return "message"
+}
+
+func (a *KafkaAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *KafkaAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	a.broker = u.Host
+	if u.Port() == "" {
+		a.broker = u.Host + ":9092"
+	}
+
+	if len(u.Path) < 2 {
+		a.topics = "*"
+	} else {
+		a.topics = u.Path[1:]
+	}
+
+	a.schemaRegistry = strings.TrimSuffix(u.Query().Get("schema_registry"), "/")
+	a.codecs = make(map[int]*goavro.Codec)
+
+	// connect
+	conn, err := kafka.Dial("tcp", a.broker)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (a KafkaAdapter) FetchTables() ([]table, error) {
+	tables := []table{}
+
+	conn, err := kafka.Dial("tcp", a.broker)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, partition := range partitions {
+		topic := partition.Topic
+
+		// skip internal topics
+		if seen[topic] || strings.HasPrefix(topic, "__") || !a.matchesTopic(topic) {
+			continue
+		}
+		seen[topic] = true
+		tables = append(tables, table{Schema: "", Name: topic})
+	}
+
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+
+	return tables, nil
+}
+
+func (a KafkaAdapter) matchesTopic(topic string) bool {
+	for _, pattern := range strings.Split(a.topics, ",") {
+		matched, err := path.Match(pattern, topic)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (a KafkaAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	conn, err := kafka.DialContext(ctx, "tcp", a.broker)
+	if err != nil {
+		return nil, err
+	}
+	partitions, err := conn.ReadPartitions(table.Name)
+	conn.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(partitions) == 0 {
+		return &tableData{ColumnNames: []string{}, ColumnValues: [][]string{}}, nil
+	}
+
+	keyMap := make(map[string]int)
+
+	columnValues := make([][]string, 0)
+
+	// spread the sample across partitions, newest messages first
+	perPartition := (limit + len(partitions) - 1) / len(partitions)
+	count := 0
+
+	for _, partition := range partitions {
+		messages, err := fetchRecentMessages(ctx, a.broker, table.Name, partition.ID, perPartition)
+		if err != nil {
+			return nil, err
+		}
+
+		count += len(messages)
+		for _, message := range messages {
+			if len(message.Key) > 0 {
+				keyMap, columnValues = addValue("_key", string(message.Key), keyMap, columnValues)
+			}
+
+			object, err := a.decodeValue(message.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			if object != nil {
+				keyMap, columnValues = scanSource(object, "", keyMap, columnValues)
+			} else if len(message.Value) > 0 {
+				keyMap, columnValues = addValue("_value", string(message.Value), keyMap, columnValues)
+			}
+		}
+	}
+
+	columnNames := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: count}, nil
+}
+
+func fetchRecentMessages(ctx context.Context, broker string, topic string, partition int, limit int) ([]kafka.Message, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", broker, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, err
+	}
+
+	start := last - int64(limit)
+	if start < first {
+		start = first
+	}
+	if start >= last {
+		return nil, nil
+	}
+
+	_, err = conn.Seek(start, kafka.SeekAbsolute)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+
+	messages := []kafka.Message{}
+
+	offset := start
+	for offset < last && len(messages) < limit {
+		batch := conn.ReadBatch(1, 10e6)
+		for {
+			message, err := batch.ReadMessage()
+			if err != nil {
+				break
+			}
+			messages = append(messages, message)
+		}
+		err := batch.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// stop if no progress
+		if batch.Offset() <= offset {
+			break
+		}
+		offset = batch.Offset()
+	}
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// returns nil if the value is not a JSON or Avro object
+func (a KafkaAdapter) decodeValue(value []byte) (map[string]interface{}, error) {
+	var object map[string]interface{}
+
+	// Confluent wire format: magic byte + 4-byte schema id + Avro binary
+	if len(value) > 5 && value[0] == 0 && a.schemaRegistry != "" {
+		codec, err := a.fetchCodec(int(binary.BigEndian.Uint32(value[1:5])))
+		if err != nil {
+			return nil, err
+		}
+
+		native, _, err := codec.NativeFromBinary(value[5:])
+		if err != nil {
+			return nil, nil
+		}
+
+		// standard JSON avoids union type names in field paths
+		value, err = codec.TextualFromNative(nil, native)
+		if err != nil {
+			return nil, nil
+		}
+	}
+
+	if json.Unmarshal(value, &object) != nil {
+		return nil, nil
+	}
+	return object, nil
+}
+
+func (a KafkaAdapter) fetchCodec(id int) (*goavro.Codec, error) {
+	codec, ok := a.codecs[id]
+	if ok {
+		return codec, nil
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/schemas/ids/%d", a.schemaRegistry, id))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching schema %d: %s", id, resp.Status)
+	}
+
+	var r struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	codec, err = goavro.NewCodecForStandardJSONFull(r.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	a.codecs[id] = codec
+	return codec, nil
+}
+
+func addValue(key string, value string, keyMap map[string]int, columnValues [][]string) (map[string]int, [][]string) {
+	i, ok := keyMap[key]
+	if !ok {
+		i = len(keyMap)
+		keyMap[key] = i
+		columnValues = append(columnValues, []string{})
+	}
+	columnValues[i] = append(columnValues[i], value)
+	return keyMap, columnValues
+}
@@ -0,0 +1,635 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesAdapter scans pod environments and config maps, including config mounted in pods
+// secrets are not read, since they are expected to hold credentials
+type KubernetesAdapter struct {
+	endpoint string
+	token    string
+	client   *http.Client
+	// namespace from the context, used if namespaces can't be listed
+	namespace string
+	// patterns, like prod-*
+	namespaces        []string
+	excludeNamespaces []string
+	selector          string
+	resources         []string
+}
+
+var kubernetesResources = []string{"pods", "configmaps"}
+
+const kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+func (a *KubernetesAdapter) TableName() string {
+	return "resource"
+}
+
+func (a *KubernetesAdapter) RowName() string {
+	return "object"
+}
+
+func (a *KubernetesAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+// kubernetes:// uses the current context, kubernetes://name uses a context by name
+// and options are query parameters, like ?namespaces=prod-*&exclude_namespaces=prod-sandbox&selector=app=web
+func (a *KubernetesAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	err = a.setOptions(u.Query())
+	if err != nil {
+		return err
+	}
+
+	// in-cluster config is only used without a kubeconfig, so a mounted token is not used by accident
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			kubeconfig = filepath.Join(home, ".kube", "config")
+		}
+	}
+	_, statErr := os.Stat(kubeconfig)
+	if statErr != nil && u.Host == "" && os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		err = a.initInCluster()
+	} else {
+		err = a.initKubeconfig(kubeconfig, u.Host)
+	}
+	if err != nil {
+		return err
+	}
+
+	// check credentials
+	var result struct{}
+	return a.get(context.Background(), "/version", nil, &result)
+}
+
+func (a *KubernetesAdapter) setOptions(query url.Values) error {
+	a.namespaces = splitOption(query.Get("namespaces"))
+	a.excludeNamespaces = splitOption(query.Get("exclude_namespaces"))
+	a.selector = query.Get("selector")
+
+	a.resources = splitOption(query.Get("resources"))
+	if len(a.resources) == 0 {
+		a.resources = kubernetesResources
+	}
+	for _, resource := range a.resources {
+		if !stringInSlice(resource, kubernetesResources) {
+			return fmt.Errorf("Invalid resource: %s\nValid resources are %s", resource, strings.Join(kubernetesResources, ", "))
+		}
+	}
+
+	for _, pattern := range append(append([]string{}, a.namespaces...), a.excludeNamespaces...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid namespace pattern: %s", pattern)
+		}
+	}
+	return nil
+}
+
+func splitOption(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (a *KubernetesAdapter) initInCluster() error {
+	token, err := os.ReadFile(filepath.Join(kubernetesServiceAccountDir, "token"))
+	if err != nil {
+		return err
+	}
+	namespace, _ := os.ReadFile(filepath.Join(kubernetesServiceAccountDir, "namespace"))
+
+	ca, err := os.ReadFile(filepath.Join(kubernetesServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return errors.New("invalid service account CA certificate")
+	}
+
+	endpoint := "https://" + strings.TrimSuffix(os.Getenv("KUBERNETES_SERVICE_HOST")+":"+os.Getenv("KUBERNETES_SERVICE_PORT"), ":")
+	a.initEndpoint(endpoint, strings.TrimSpace(string(token)), &tls.Config{RootCAs: pool})
+	a.namespace = strings.TrimSpace(string(namespace))
+	return nil
+}
+
+// only the fields pdscan uses, so configs with exec credentials are reported instead of ignored
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string      `yaml:"token"`
+			TokenFile             string      `yaml:"tokenFile"`
+			ClientCertificate     string      `yaml:"client-certificate"`
+			ClientCertificateData string      `yaml:"client-certificate-data"`
+			ClientKey             string      `yaml:"client-key"`
+			ClientKeyData         string      `yaml:"client-key-data"`
+			Exec                  interface{} `yaml:"exec"`
+			AuthProvider          interface{} `yaml:"auth-provider"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+func (a *KubernetesAdapter) initKubeconfig(filename string, contextName string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("Error reading kubeconfig: %s", err)
+	}
+
+	var config kubeconfig
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return fmt.Errorf("Error parsing kubeconfig: %s", err)
+	}
+
+	// relative paths are relative to the kubeconfig
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(filepath.Dir(filename), p)
+	}
+
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	found := false
+	var clusterName, userName string
+	for _, c := range config.Contexts {
+		if c.Name == contextName {
+			found = true
+			clusterName = c.Context.Cluster
+			userName = c.Context.User
+			a.namespace = c.Context.Namespace
+		}
+	}
+	if !found {
+		return fmt.Errorf("Context not found in kubeconfig: %s", contextName)
+	}
+
+	tlsConfig := &tls.Config{}
+	endpoint := ""
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		endpoint = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+
+		ca, err := kubeconfigData(c.Cluster.CertificateAuthorityData, resolve(c.Cluster.CertificateAuthority))
+		if err != nil {
+			return err
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return errors.New("invalid certificate authority in kubeconfig")
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if endpoint == "" {
+		return fmt.Errorf("Cluster not found in kubeconfig: %s", clusterName)
+	}
+
+	token := ""
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+		if u.User.Exec != nil || u.User.AuthProvider != nil {
+			return errors.New("exec and auth provider credentials in kubeconfig are not supported, use a token or client certificate")
+		}
+
+		token = u.User.Token
+		if u.User.TokenFile != "" {
+			data, err := os.ReadFile(resolve(u.User.TokenFile))
+			if err != nil {
+				return err
+			}
+			token = strings.TrimSpace(string(data))
+		}
+
+		cert, err := kubeconfigData(u.User.ClientCertificateData, resolve(u.User.ClientCertificate))
+		if err != nil {
+			return err
+		}
+		key, err := kubeconfigData(u.User.ClientKeyData, resolve(u.User.ClientKey))
+		if err != nil {
+			return err
+		}
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return fmt.Errorf("invalid client certificate in kubeconfig: %s", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+
+	a.initEndpoint(endpoint, token, tlsConfig)
+	return nil
+}
+
+// inline data is base64, like certificate-authority-data
+func kubeconfigData(data string, filename string) ([]byte, error) {
+	if data != "" {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, errors.New("invalid base64 data in kubeconfig")
+		}
+		return decoded, nil
+	}
+	if filename != "" {
+		return os.ReadFile(filename)
+	}
+	return nil, nil
+}
+
+func (a *KubernetesAdapter) initEndpoint(endpoint string, token string, tlsConfig *tls.Config) {
+	a.endpoint = strings.TrimSuffix(endpoint, "/")
+	a.token = token
+	a.client = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+}
+
+func (a KubernetesAdapter) FetchTables() ([]table, error) {
+	namespaces, err := a.fetchNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := []table{}
+	for _, namespace := range namespaces {
+		if !a.includesNamespace(namespace) {
+			continue
+		}
+		for _, resource := range a.resources {
+			tables = append(tables, table{Schema: namespace, Name: resource})
+		}
+	}
+	return tables, nil
+}
+
+// namespaces without patterns don't need to be listed, which needs cluster-wide permission
+func (a KubernetesAdapter) fetchNamespaces() ([]string, error) {
+	exact := len(a.namespaces) > 0
+	for _, pattern := range a.namespaces {
+		if strings.ContainsAny(pattern, "*?[") {
+			exact = false
+		}
+	}
+	if exact {
+		return a.namespaces, nil
+	}
+
+	var result struct {
+		Items []kubernetesObject `json:"items"`
+	}
+	err := a.get(context.Background(), "/api/v1/namespaces", nil, &result)
+	if err != nil {
+		// a user limited to one namespace can still scan it
+		if strings.HasPrefix(err.Error(), "[403") && a.namespace != "" && len(a.namespaces) == 0 {
+			return []string{a.namespace}, nil
+		}
+		return nil, err
+	}
+
+	namespaces := []string{}
+	for _, item := range result.Items {
+		namespaces = append(namespaces, item.Metadata.Name)
+	}
+	return namespaces, nil
+}
+
+func (a KubernetesAdapter) includesNamespace(namespace string) bool {
+	for _, pattern := range a.excludeNamespaces {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return false
+		}
+	}
+	if len(a.namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range a.namespaces {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+type kubernetesObject struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Data map[string]string `json:"data"`
+	Spec kubernetesPodSpec `json:"spec"`
+}
+
+type kubernetesPodSpec struct {
+	Containers     []kubernetesContainer `json:"containers"`
+	InitContainers []kubernetesContainer `json:"initContainers"`
+	Volumes        []struct {
+		Name      string               `json:"name"`
+		ConfigMap *kubernetesConfigRef `json:"configMap"`
+		Projected *struct {
+			Sources []struct {
+				ConfigMap *kubernetesConfigRef `json:"configMap"`
+			} `json:"sources"`
+		} `json:"projected"`
+	} `json:"volumes"`
+}
+
+type kubernetesContainer struct {
+	Env []struct {
+		Name      string `json:"name"`
+		Value     string `json:"value"`
+		ValueFrom *struct {
+			ConfigMapKeyRef *struct {
+				Name string `json:"name"`
+				Key  string `json:"key"`
+			} `json:"configMapKeyRef"`
+		} `json:"valueFrom"`
+	} `json:"env"`
+	EnvFrom []struct {
+		Prefix       string               `json:"prefix"`
+		ConfigMapRef *kubernetesConfigRef `json:"configMapRef"`
+	} `json:"envFrom"`
+	VolumeMounts []struct {
+		Name      string `json:"name"`
+		MountPath string `json:"mountPath"`
+		SubPath   string `json:"subPath"`
+	} `json:"volumeMounts"`
+}
+
+type kubernetesConfigRef struct {
+	Name  string `json:"name"`
+	Items []struct {
+		Key  string `json:"key"`
+		Path string `json:"path"`
+	} `json:"items"`
+}
+
+// each pod or config map is a row, with env vars, mounted files, and config map keys as columns
+func (a KubernetesAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	objects, err := a.fetchObjects(ctx, table.Schema, table.Name, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	keyMap := make(map[string]int)
+	columnValues := make([][]string, 0)
+
+	if table.Name == "configmaps" {
+		for _, object := range objects {
+			keyMap, columnValues = scanSource(stringMap(object.Data), "", keyMap, columnValues)
+		}
+	} else {
+		configMaps := make(map[string]map[string]string)
+		for _, object := range objects {
+			values, err := a.podValues(ctx, table.Schema, object.Spec, configMaps)
+			if err != nil {
+				return nil, err
+			}
+			keyMap, columnValues = scanSource(stringMap(values), "", keyMap, columnValues)
+		}
+	}
+
+	columnNames := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: len(objects)}, nil
+}
+
+func stringMap(values map[string]string) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	return m
+}
+
+// values the containers see, so config maps are checked where they are used
+// env vars are keyed by name, and mounted config by file path, like /etc/app/settings.yml
+func (a KubernetesAdapter) podValues(ctx context.Context, namespace string, spec kubernetesPodSpec, configMaps map[string]map[string]string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	configMap := func(name string) (map[string]string, error) {
+		data, ok := configMaps[name]
+		if !ok {
+			var object kubernetesObject
+			err := a.get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/configmaps/"+url.PathEscape(name), nil, &object)
+			// optional config maps may not exist
+			if err != nil && !strings.HasPrefix(err.Error(), "[404") {
+				return nil, err
+			}
+			data = object.Data
+			configMaps[name] = data
+		}
+		return data, nil
+	}
+
+	// files in each volume, by path in the volume
+	volumeFiles := make(map[string]map[string]string)
+	for _, volume := range spec.Volumes {
+		refs := []*kubernetesConfigRef{volume.ConfigMap}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				refs = append(refs, source.ConfigMap)
+			}
+		}
+
+		files := make(map[string]string)
+		for _, ref := range refs {
+			if ref == nil {
+				continue
+			}
+			data, err := configMap(ref.Name)
+			if err != nil {
+				return nil, err
+			}
+			if len(ref.Items) == 0 {
+				for key, value := range data {
+					files[key] = value
+				}
+			}
+			for _, item := range ref.Items {
+				if value, ok := data[item.Key]; ok {
+					files[item.Path] = value
+				}
+			}
+		}
+		if len(files) > 0 {
+			volumeFiles[volume.Name] = files
+		}
+	}
+
+	for _, container := range append(append([]kubernetesContainer{}, spec.InitContainers...), spec.Containers...) {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef == nil {
+				continue
+			}
+			data, err := configMap(envFrom.ConfigMapRef.Name)
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range data {
+				values[envFrom.Prefix+key] = value
+			}
+		}
+
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				data, err := configMap(env.ValueFrom.ConfigMapKeyRef.Name)
+				if err != nil {
+					return nil, err
+				}
+				if value, ok := data[env.ValueFrom.ConfigMapKeyRef.Key]; ok {
+					values[env.Name] = value
+				}
+			} else if env.Value != "" {
+				values[env.Name] = env.Value
+			}
+		}
+
+		for _, mount := range container.VolumeMounts {
+			for file, value := range volumeFiles[mount.Name] {
+				if mount.SubPath != "" {
+					if file == mount.SubPath {
+						values[mount.MountPath] = value
+					}
+					continue
+				}
+				values[path.Join(mount.MountPath, file)] = value
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// lists pages of objects, since namespaces can have thousands of pods
+func (a KubernetesAdapter) fetchObjects(ctx context.Context, namespace string, resource string, limit int) ([]kubernetesObject, error) {
+	objects := []kubernetesObject{}
+	continueToken := ""
+	for len(objects) < limit {
+		params := url.Values{}
+		params.Set("limit", strconv.Itoa(limit-len(objects)))
+		if a.selector != "" {
+			params.Set("labelSelector", a.selector)
+		}
+		if continueToken != "" {
+			params.Set("continue", continueToken)
+		}
+
+		var result struct {
+			Metadata struct {
+				Continue string `json:"continue"`
+			} `json:"metadata"`
+			Items []kubernetesObject `json:"items"`
+		}
+		err := a.get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/"+resource, params, &result)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, result.Items...)
+
+		continueToken = result.Metadata.Continue
+		if continueToken == "" {
+			break
+		}
+	}
+	if len(objects) > limit {
+		objects = objects[:limit]
+	}
+	return objects, nil
+}
+
+func (a KubernetesAdapter) get(ctx context.Context, path string, params url.Values, result interface{}) error {
+	urlStr := a.endpoint + path
+	if len(params) > 0 {
+		urlStr += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("[%s] %s", resp.Status, e.Message)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("error parsing the response body: %s", err)
+	}
+	return nil
+}
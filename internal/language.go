@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// common words that are rare in other languages, like articles and conjunctions
+// words shared by languages, like de and que, are not included
+var languageWords = map[string][]string{
+	"en": {"the", "and", "was", "with", "for", "this", "that", "you", "have", "from", "will", "your", "our", "they", "are", "of", "to", "not", "been", "which"},
+	"de": {"der", "das", "und", "ist", "nicht", "mit", "ein", "eine", "ich", "sie", "wir", "auf", "für", "den", "dem", "von", "zu", "sich", "auch", "wird", "bei"},
+	"fr": {"le", "les", "et", "est", "une", "des", "du", "pour", "avec", "dans", "pas", "qui", "sur", "nous", "vous", "sont", "au", "ce", "mais", "cette"},
+	"es": {"el", "los", "las", "y", "está", "por", "para", "se", "su", "al", "como", "pero", "muy", "sus", "fue", "este", "esta", "también", "hay", "lo"},
+	"it": {"gli", "è", "di", "della", "per", "che", "non", "sono", "sul", "nel", "anche", "ma", "questo", "ci", "alla", "dei", "delle", "ha", "come", "più"},
+	"nl": {"het", "een", "van", "niet", "met", "op", "voor", "dat", "zijn", "ik", "je", "ook", "wordt", "bij", "aan", "naar", "er", "maar", "om", "hij"},
+}
+
+// common words needed before a language is reported, so values like names and codes are not
+const languageMinWords = 10
+
+var languageLookup = func() map[string]string {
+	lookup := make(map[string]string)
+	for language, words := range languageWords {
+		for _, word := range words {
+			lookup[word] = language
+		}
+	}
+	return lookup
+}()
+
+// words are only counted when needed, since it is slower than the other rules
+func newLanguageWords(matchConfig *MatchConfig) map[string]int {
+	for _, rule := range matchConfig.RegexRules {
+		if rule.Language != "" {
+			return make(map[string]int)
+		}
+	}
+	return nil
+}
+
+// counts common words of each language in free text
+func countLanguageWords(counts map[string]int, v string) {
+	words := strings.FieldsFunc(strings.ToLower(v), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		if language, ok := languageLookup[word]; ok {
+			counts[language]++
+		}
+	}
+}
+
+// the language with the most common words, if it has at least twice as many as any other
+func dominantLanguage(counts map[string]int) string {
+	best := ""
+	bestCount := 0
+	nextCount := 0
+	for language, count := range counts {
+		if count > bestCount {
+			nextCount = bestCount
+			best = language
+			bestCount = count
+		} else if count > nextCount {
+			nextCount = count
+		}
+	}
+
+	if bestCount < languageMinWords || bestCount < 2*nextCount {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// values shared before overlap is reported, so a few common values, like test emails, are not
+const lineageMinShared = 5
+
+// fraction of values of a source that must also appear in the other source
+const lineageMinOverlap = 0.5
+
+// lineageHint is overlap of matched values between sources, like a table copied by an export pipeline
+type lineageHint struct {
+	DisplayName string
+	From        string
+	To          string
+	Shared      int
+	Total       int
+}
+
+// compares values of value matches with the same rule across columns, fields, and files
+// values are sampled, so overlap is an estimate
+func findLineage(matchList []ruleMatch, matchConfig *MatchConfig) []lineageHint {
+	values := make(map[string]map[string]map[string]bool)
+	displayNames := make(map[string]string)
+
+	for _, match := range matchList {
+		if match.MatchType != "value" {
+			continue
+		}
+
+		// values of table columns are the full value, so extract matches like with files
+		rule := findRegexRule(match.RuleName, matchConfig.RegexRules)
+		set := make(map[string]bool)
+		for _, v := range match.MatchedData {
+			found := []string{v}
+			if rule != nil {
+				found = rule.findAll(v)
+			}
+			for _, f := range found {
+				set[strings.ToLower(f)] = true
+			}
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		if values[match.RuleName] == nil {
+			values[match.RuleName] = make(map[string]map[string]bool)
+		}
+		values[match.RuleName][match.Identifier] = set
+		displayNames[match.RuleName] = match.DisplayName
+	}
+
+	rules := make([]string, 0, len(values))
+	for rule := range values {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	hints := []lineageHint{}
+	for _, rule := range rules {
+		identifiers := make([]string, 0, len(values[rule]))
+		for identifier := range values[rule] {
+			identifiers = append(identifiers, identifier)
+		}
+		sort.Strings(identifiers)
+
+		for _, from := range identifiers {
+			for _, to := range identifiers {
+				if from == to {
+					continue
+				}
+
+				shared := 0
+				for v := range values[rule][from] {
+					if values[rule][to][v] {
+						shared++
+					}
+				}
+				total := len(values[rule][from])
+				if shared >= lineageMinShared && float64(shared)/float64(total) >= lineageMinOverlap {
+					hints = append(hints, lineageHint{DisplayName: displayNames[rule], From: from, To: to, Shared: shared, Total: total})
+				}
+			}
+		}
+	}
+	return hints
+}
+
+func printLineage(writer io.Writer, hints []lineageHint) {
+	if len(hints) == 0 {
+		fmt.Fprintln(writer, "\nNo shared values found between sources")
+		return
+	}
+
+	fmt.Fprintln(writer, "\nPossible lineage (shared values between sources):")
+	for _, hint := range hints {
+		fmt.Fprintf(writer, "    %d%% of %s in %s also appear in %s (%d of %d sampled values)\n", hint.Shared*100/hint.Total, hint.DisplayName, hint.From, hint.To, hint.Shared, hint.Total)
+	}
+}
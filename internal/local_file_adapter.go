@@ -6,7 +6,11 @@ import (
 )
 
 type LocalFileAdapter struct {
-	url string
+	url            string
+	readLimiter    *readLimiter
+	modifiedFilter modifiedFilter
+	// sizes from listing files, for the time budget
+	sizes map[string]int64
 }
 
 func (a *LocalFileAdapter) ObjectName() string {
@@ -19,21 +23,32 @@ func (a *LocalFileAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
 
 func (a *LocalFileAdapter) Init(url string) error {
 	a.url = url
+	a.sizes = make(map[string]int64)
 	return nil
 }
 
+func (a *LocalFileAdapter) listedSize(file string) int64 {
+	return a.sizes[file]
+}
+
+func (a *LocalFileAdapter) setModifiedFilter(filter modifiedFilter) {
+	a.modifiedFilter = filter
+}
+
 func (a LocalFileAdapter) FetchFiles() ([]string, error) {
 	urlStr := a.url
-	var files []string
+	var objects []modifiedObject
 
 	root := urlStr[7:]
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err == nil && !info.IsDir() {
-			files = append(files, path)
+			objects = append(objects, modifiedObject{path, info.ModTime()})
+			a.sizes[path] = info.Size()
 		}
 		return nil
 	})
 
+	files := a.modifiedFilter.apply(objects)
 	if err != nil {
 		return files, err
 	}
@@ -41,6 +56,14 @@ func (a LocalFileAdapter) FetchFiles() ([]string, error) {
 	return files, nil
 }
 
+func (a LocalFileAdapter) FileSize(filename string) (int64, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
 // TODO read metadata for certain file types
 func (a LocalFileAdapter) FindFileMatches(filename string, matchFinder *MatchFinder) error {
 	f, err := os.Open(filename)
@@ -48,6 +71,10 @@ func (a LocalFileAdapter) FindFileMatches(filename string, matchFinder *MatchFin
 		return err
 	}
 	defer f.Close()
+	setReadDeadline(matchFinder.ctx, f)
 
+	if a.readLimiter != nil {
+		return processFile(throttledReader{f, a.readLimiter}, matchFinder)
+	}
 	return processFile(f, matchFinder)
 }
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// national IDs are only checked in text of the language they are used with,
+// since their formats, like 9 or 11 digits, are common in other data
+
+var deTaxIdRegex = regexp.MustCompile(`\b[1-9]\d(?: ?\d{3}){3}\b`)
+
+var frNirRegex = regexp.MustCompile(`\b[12] ?\d{2} ?(?:0[1-9]|1[0-2]|[2-9]\d) ?(?:\d{2}|2[AB]) ?\d{3} ?\d{3} ?\d{2}\b`)
+
+var esDniRegex = regexp.MustCompile(`\b[XYZ]?\d{7,8}-?[A-Z]\b`)
+
+var itFiscalCodeRegex = regexp.MustCompile(`\b[A-Z]{6}\d{2}[ABCDEHLMPRST]\d{2}[A-Z]\d{3}[A-Z]\b`)
+
+var nlBsnRegex = regexp.MustCompile(`\b\d{4}\.?\d{2}\.?\d{3}\b`)
+
+func localeDigits(v string) string {
+	return strings.NewReplacer(" ", "", ".", "", "-", "").Replace(v)
+}
+
+// Steuerliche Identifikationsnummer, with an ISO 7064 MOD 11,10 check digit
+// one digit of the first ten appears two or three times
+func validDeTaxId(v string) bool {
+	digits := localeDigits(v)
+	if len(digits) != 11 {
+		return false
+	}
+
+	counts := make(map[byte]int)
+	for i := 0; i < 10; i++ {
+		counts[digits[i]]++
+	}
+	repeated := 0
+	for _, count := range counts {
+		if count > 3 {
+			return false
+		} else if count > 1 {
+			repeated++
+		}
+	}
+	if repeated != 1 {
+		return false
+	}
+
+	product := 10
+	for i := 0; i < 10; i++ {
+		sum := (int(digits[i]-'0') + product) % 10
+		if sum == 0 {
+			sum = 10
+		}
+		product = (sum * 2) % 11
+	}
+	check := 11 - product
+	if check == 10 {
+		check = 0
+	}
+	return check == int(digits[10]-'0')
+}
+
+// numéro de sécurité sociale, with a key of 97 minus the number mod 97
+// Corsica departments 2A and 2B count as 19 and 18
+func validFrNir(v string) bool {
+	digits := localeDigits(v)
+	if len(digits) != 15 {
+		return false
+	}
+
+	number := digits[:13]
+	switch number[5:7] {
+	case "2A":
+		number = number[:5] + "19" + number[7:]
+	case "2B":
+		number = number[:5] + "18" + number[7:]
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return false
+	}
+	key, err := strconv.ParseInt(digits[13:], 10, 64)
+	if err != nil {
+		return false
+	}
+	return key == 97-n%97
+}
+
+// DNI and NIE, with a check letter
+// NIEs start with X, Y, or Z, which count as 0, 1, and 2
+func validEsDni(v string) bool {
+	value := localeDigits(v)
+	number := value[:len(value)-1]
+	switch number[0] {
+	case 'X':
+		number = "0" + number[1:]
+	case 'Y':
+		number = "1" + number[1:]
+	case 'Z':
+		number = "2" + number[1:]
+	}
+	if len(number) != 8 {
+		return false
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return false
+	}
+	return value[len(value)-1] == "TRWAGMYFPDXBNJZSQVHLCKE"[n%23]
+}
+
+// values of characters in odd positions for the codice fiscale check character
+var itFiscalCodeOdd = []int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21, 2, 4, 18, 20, 11, 3, 6, 8, 12, 14, 16, 10, 22, 25, 24, 23}
+
+// codice fiscale, with a check character
+func validItFiscalCode(v string) bool {
+	if len(v) != 16 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 15; i++ {
+		value := int(v[i] - 'A')
+		if v[i] >= '0' && v[i] <= '9' {
+			value = int(v[i] - '0')
+		}
+		if i%2 == 0 {
+			sum += itFiscalCodeOdd[value]
+		} else {
+			sum += value
+		}
+	}
+	return v[15] == byte('A'+sum%26)
+}
+
+// burgerservicenummer, with the eleven test
+func validNlBsn(v string) bool {
+	digits := localeDigits(v)
+	if len(digits) != 9 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 8; i++ {
+		sum += int(digits[i]-'0') * (9 - i)
+	}
+	sum -= int(digits[8] - '0')
+	return sum != 0 && sum%11 == 0
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// MailchimpAdapter scans members of each audience with the Mailchimp Marketing API
+type MailchimpAdapter struct {
+	RestAdapter
+}
+
+func (a *MailchimpAdapter) marketingPlatform() {}
+
+func (a *MailchimpAdapter) TableName() string {
+	return "audience"
+}
+
+func (a *MailchimpAdapter) RowName() string {
+	return "member"
+}
+
+func (a *MailchimpAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *MailchimpAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	key, ok := u.User.Password()
+	if !ok {
+		key = os.Getenv("MAILCHIMP_API_KEY")
+	}
+
+	// keys end with the data center, like -us6
+	i := strings.LastIndexByte(key, '-')
+	if i == -1 {
+		return errors.New("no API key specified")
+	}
+
+	return a.initEndpoint("https://"+key[i+1:]+".api.mailchimp.com/3.0", key)
+}
+
+// emails are expected, so only merge fields, like FNAME and custom fields, and tags are scanned
+func (a *MailchimpAdapter) initEndpoint(endpoint string, key string) error {
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "basic"
+	config.Auth.Username = "pdscan"
+	config.Auth.Password = key
+	config.Resources = []restResource{{Name: "lists", Path: "/lists"}}
+	err := a.init(config)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	_, err = a.client.get(context.Background(), endpoint+"/lists?count=1000&fields=lists.id,lists.name", &result)
+	if err != nil {
+		return err
+	}
+	records, err := restRecords(result, "lists")
+	if err != nil {
+		return err
+	}
+
+	config.Resources = []restResource{}
+	for _, record := range records {
+		id, _ := record["id"].(string)
+		name, _ := record["name"].(string)
+		config.Resources = append(config.Resources, restResource{
+			Name:       name,
+			Path:       "/lists/" + url.PathEscape(id) + "/members",
+			Items:      "members",
+			Fields:     []string{"merge_fields", "tags"},
+			Pagination: restPagination{Type: "offset", SizeParam: "count", Size: 1000},
+		})
+	}
+	return nil
+}
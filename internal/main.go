@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -25,24 +27,156 @@ type ScanOpts struct {
 	Debug       bool
 	Formatter   Formatter
 	MatchConfig *MatchConfig
+	MaxFileSize int64
+	Scope       *scope
+	Ignore      *ignoreList
+	Coverage    *coverage
+	TimeBudget  time.Duration
+	MaxValues   int
+	Redactor    *redactor
+	// max time for each table or file, if set
+	ObjectTimeout time.Duration
+	// percent of files to scan by prefix, if set
+	PrefixSamples []prefixSample
+	// max bytes of sampled values for each column, if set
+	ColumnBudget int64
+	// also scan deleted data in SQLite files and text anywhere in binary files
+	Forensic bool
+	// records each scanned table and its matches in the state file, if set
+	State *watermarkState
 }
 
-func Main(urlStr string, showData bool, showAll bool, limit int, processes int, only string, except string, minCount int, pattern string, debug bool, format string) error {
-	runtime.GOMAXPROCS(processes)
+// MainOpts are the options for a scan, one for each command-line flag
+type MainOpts struct {
+	UrlStr          string
+	ShowData        bool
+	ShowAll         bool
+	Limit           int
+	Processes       int
+	Only            string
+	Except          string
+	MinCount        int
+	Pattern         string
+	Debug           bool
+	Format          string
+	MaxFileSize     string
+	Query           string
+	ScopeFile       string
+	TemplateFile    string
+	Detectors       []string
+	SecretMinLength int
+	SecretEntropy   float64
+	Since           string
+	StateFile       string
+	NotifyUrl       string
+	NotifySlack     string
+	NotifyThreshold int
+	IgnoreFile      string
+	StatsFile       string
+	TimeBudget      string
+	// labels for structured output, like env=prod
+	Labels           []string
+	Nice             bool
+	IoLimit          string
+	Redact           string
+	MaxValues        int
+	PhoneRegions     string
+	TimeoutPerObject string
+	NewestFirst      bool
+	ModifiedSince    string
+	SamplePrefixes   []string
+	// AWS roles to assume in order
+	AssumeRoles []string
+	// AWS accounts, like 111111111111,222222222222, or organization
+	Accounts string
+	// GCP service accounts, with delegates first and the service account to impersonate last
+	ImpersonateServiceAccount string
+	CardData                  bool
+	RulesFile                 string
+	ColumnBudget              string
+	Forensic                  bool
+	Lineage                   bool
+	HealthcheckFile           string
+	Obfuscated                bool
+}
+
+func Main(opts MainOpts) (err error) {
+	runtime.GOMAXPROCS(opts.Processes)
+
+	// record failures too, so probes can tell a failing scan from one that didn't run
+	healthcheckStart := time.Now()
+	healthcheckMatches := 0
+	var labels map[string]string
+	if opts.HealthcheckFile != "" {
+		defer func() {
+			healthcheckErr := writeHealthcheck(opts.HealthcheckFile, opts.UrlStr, labels, healthcheckStart, healthcheckMatches, err)
+			if err == nil {
+				err = healthcheckErr
+			}
+		}()
+	}
 
-	formatter, found := Formatters[format]
+	newFormatter, found := Formatters[opts.Format]
 	if !found {
 		arr := make([]string, 0, len(Formatters))
 		for k := range Formatters {
 			arr = append(arr, k)
 		}
 		sort.Strings(arr)
-		return fmt.Errorf("Invalid format: %s\nValid formats are %s", format, strings.Join(arr, ", "))
+		return fmt.Errorf("Invalid format: %s\nValid formats are %s", opts.Format, strings.Join(arr, ", "))
+	}
+
+	labels, err = parseLabels(opts.Labels)
+	if err != nil {
+		return err
+	}
+
+	formatter := newFormatter()
+	if reporter, ok := formatter.(labelReporter); ok {
+		reporter.setLabels(labels)
+	}
+	scanCoverage := &coverage{}
+	if reporter, ok := formatter.(coverageReporter); ok {
+		reporter.setCoverage(opts.UrlStr, scanCoverage)
+	}
+	if templateFormatter, ok := formatter.(*TemplateFormatter); ok {
+		err := templateFormatter.Load(opts.TemplateFile, opts.UrlStr)
+		if err != nil {
+			return err
+		}
+	} else if opts.TemplateFile != "" {
+		return fmt.Errorf("--template requires --format template")
+	}
+
+	var maxFileSizeBytes int64
+	if opts.MaxFileSize != "" {
+		size, err := parseSize(opts.MaxFileSize)
+		if err != nil {
+			return err
+		}
+		maxFileSizeBytes = size
 	}
 
 	matchConfig := NewMatchConfig()
-	if pattern != "" {
-		regex, err := regexp.Compile(pattern)
+	if opts.CardData {
+		matchConfig.RegexRules = append(append([]regexRule{}, matchConfig.RegexRules...), cardDataRegexRules...)
+		matchConfig.NameRules = append(append([]nameRule{}, matchConfig.NameRules...), cardDataNameRules...)
+	}
+	if opts.Obfuscated {
+		matchConfig.RegexRules = append(append([]regexRule{}, matchConfig.RegexRules...), obfuscatedRegexRules...)
+	}
+	if _, ok := newAdapter(opts.UrlStr, opts.Query).(marketingAdapter); ok {
+		matchConfig.RegexRules = append(append([]regexRule{}, matchConfig.RegexRules...), marketingRegexRules...)
+		matchConfig.NameRules = append(append([]nameRule{}, matchConfig.NameRules...), marketingNameRules...)
+	}
+	if opts.RulesFile != "" {
+		err := addRulesFile(&matchConfig, opts.RulesFile)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.Pattern != "" {
+		regex, err := regexp.Compile(opts.Pattern)
 		if err != nil {
 			return err
 		}
@@ -50,66 +184,419 @@ func Main(urlStr string, showData bool, showAll bool, limit int, processes int,
 		matchConfig.NameRules = matchConfig.NameRules[:0]
 		matchConfig.MultiNameRules = matchConfig.MultiNameRules[:0]
 		matchConfig.TokenRules = matchConfig.TokenRules[:0]
+		matchConfig.EntropyRules = matchConfig.EntropyRules[:0]
+		matchConfig.FormatRules = matchConfig.FormatRules[:0]
 	} else {
-		if except != "" {
-			err := updateRules(&matchConfig, except, true)
+		if opts.Except != "" {
+			err := updateRules(&matchConfig, opts.Except, true)
 			if err != nil {
 				return err
 			}
 		}
-		if only != "" {
-			err := updateRules(&matchConfig, only, false)
+		if opts.Only != "" {
+			err := updateRules(&matchConfig, opts.Only, false)
 			if err != nil {
 				return err
 			}
 		}
 	}
-	matchConfig.MinCount = minCount
+	matchConfig.MinCount = opts.MinCount
 
-	var adapter Adapter
-	if strings.HasPrefix(urlStr, "file://") {
-		adapter = &LocalFileAdapter{}
-	} else if strings.HasPrefix(urlStr, "s3://") {
-		adapter = &S3Adapter{}
-	} else if strings.HasPrefix(urlStr, "mongodb://") {
-		adapter = &MongodbAdapter{}
-	} else if strings.HasPrefix(urlStr, "redis://") {
-		adapter = &RedisAdapter{}
-	} else if strings.HasPrefix(urlStr, "elasticsearch+http://") || strings.HasPrefix(urlStr, "elasticsearch+https://") {
-		adapter = &ElasticsearchAdapter{}
-	} else if strings.HasPrefix(urlStr, "opensearch+http://") || strings.HasPrefix(urlStr, "opensearch+https://") {
-		adapter = &ElasticsearchAdapter{}
-	} else {
-		adapter = &SqlAdapter{}
+	if opts.SecretMinLength < 1 {
+		return fmt.Errorf("secret-min-length must be positive")
+	}
+	if opts.SecretEntropy <= 0 {
+		return fmt.Errorf("secret-entropy must be positive")
+	}
+	newEntropyRules := []entropyRule{}
+	for _, rule := range matchConfig.EntropyRules {
+		rule.MinLength = opts.SecretMinLength
+		rule.Threshold = opts.SecretEntropy
+		newEntropyRules = append(newEntropyRules, rule)
+	}
+	matchConfig.EntropyRules = newEntropyRules
+
+	if opts.PhoneRegions != "" {
+		regions, err := parsePhoneRegions(opts.PhoneRegions)
+		if err != nil {
+			return err
+		}
+		newRegexRules := []regexRule{}
+		for _, rule := range matchConfig.RegexRules {
+			// validated numbers are reported with high confidence
+			if rule.Name == "phone" {
+				valid := validPhone(regions)
+				validFormat := rule.Valid
+				rule.Confidence = "high"
+				rule.Valid = func(v string) bool { return validFormat(v) && valid(v) }
+			}
+			newRegexRules = append(newRegexRules, rule)
+		}
+		matchConfig.RegexRules = newRegexRules
+	}
+
+	for _, command := range opts.Detectors {
+		detector, err := NewSubprocessDetector(command)
+		if err != nil {
+			return err
+		}
+		defer detector.Close()
+		matchConfig.Detectors = append(matchConfig.Detectors, detector)
+	}
+
+	adapter := newAdapter(opts.UrlStr, opts.Query)
+
+	if opts.Query != "" {
+		_, ok := adapter.(*SqlAdapter)
+		if !ok {
+			return fmt.Errorf("--query is only supported for SQL databases")
+		}
+	}
+
+	var timeBudgetDuration time.Duration
+	if opts.TimeBudget != "" {
+		duration, err := time.ParseDuration(opts.TimeBudget)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("Invalid time budget: %s", opts.TimeBudget)
+		}
+		timeBudgetDuration = duration
+	}
+
+	var objectTimeout time.Duration
+	if opts.TimeoutPerObject != "" {
+		duration, err := time.ParseDuration(opts.TimeoutPerObject)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("Invalid timeout: %s", opts.TimeoutPerObject)
+		}
+		objectTimeout = duration
+	}
+
+	if opts.IoLimit != "" {
+		localAdapter, ok := adapter.(*LocalFileAdapter)
+		if !ok {
+			return fmt.Errorf("--io-limit is only supported for local files")
+		}
+
+		rate, err := parseSize(opts.IoLimit)
+		if err != nil || rate == 0 {
+			return fmt.Errorf("Invalid IO limit: %s", opts.IoLimit)
+		}
+		localAdapter.readLimiter = newReadLimiter(rate)
+	}
+
+	if opts.MaxValues < 1 {
+		return fmt.Errorf("max-values must be positive")
+	}
+
+	var valueRedactor *redactor
+	if opts.Redact != "" {
+		valueRedactor, err = newRedactor(opts.Redact)
+		if err != nil {
+			return err
+		}
+		// redacted values are safe to show
+		opts.ShowData = true
+	}
+
+	if opts.NotifyThreshold < 1 {
+		return fmt.Errorf("notify-threshold must be positive")
+	}
+
+	var watermarks *watermarkState
+	if opts.Since != "" || opts.StateFile != "" {
+		sqlAdapter, ok := adapter.(*SqlAdapter)
+		if !ok || opts.Query != "" {
+			return fmt.Errorf("--since and --state are only supported for SQL tables")
+		}
+
+		if opts.Since != "" {
+			_, err := parseTime(opts.Since)
+			if err != nil {
+				return err
+			}
+			sqlAdapter.since = opts.Since
+		}
+
+		if opts.StateFile != "" {
+			state, err := loadWatermarks(opts.StateFile)
+			if err != nil {
+				return err
+			}
+			sqlAdapter.watermarks = state
+			watermarks = state
+
+			options := scanOptionsFingerprint(opts.Limit, opts.Only, opts.Except, opts.MinCount, opts.Pattern, opts.Detectors, opts.SecretMinLength, opts.SecretEntropy, opts.Since, opts.ScopeFile, opts.IgnoreFile, opts.PhoneRegions, opts.CardData, opts.RulesFile, opts.ColumnBudget, opts.Obfuscated)
+			resumed, discarded := state.startScan(redactUrl(opts.UrlStr), options, time.Now())
+			if discarded {
+				fmt.Fprintln(os.Stderr, "Starting a new scan (the interrupted scan in the state file has a different URL or options, or is too old)")
+			}
+			if resumed > 0 {
+				fmt.Fprintf(os.Stderr, "Resuming the scan started %s (%s already scanned)\n", state.Scan.StartedAt.Local().Format("2006-01-02 15:04:05"), pluralize(resumed, "table"))
+				if opts.ShowData {
+					fmt.Fprintln(os.Stderr, "Matches from scanned tables are shown without data")
+				}
+			}
+		}
 	}
 
-	matchList, err := adapter.Scan(ScanOpts{urlStr, showData, showAll, limit, debug, formatter, &matchConfig})
+	if opts.NewestFirst || opts.ModifiedSince != "" {
+		filterAdapter, ok := adapter.(modifiedFilterAdapter)
+		if !ok {
+			return fmt.Errorf("--newest-first and --modified-since are only supported for files, S3, and Cloud Storage")
+		}
+
+		filter := modifiedFilter{newestFirst: opts.NewestFirst}
+		if opts.ModifiedSince != "" {
+			filter.since, err = parseModifiedSince(opts.ModifiedSince, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+		filterAdapter.setModifiedFilter(filter)
+	}
+
+	if len(opts.AssumeRoles) > 0 || opts.Accounts != "" {
+		s3Adapter, ok := adapter.(*S3Adapter)
+		if !ok {
+			return fmt.Errorf("--assume-role and --accounts are only supported for S3")
+		}
+		s3Adapter.roles = opts.AssumeRoles
+
+		if opts.Accounts != "" {
+			s3Adapter.accounts, err = parseAccounts(opts.Accounts)
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(opts.UrlStr+strings.Join(opts.AssumeRoles, ""), "{account}") {
+				return fmt.Errorf("--accounts requires {account} in a role or the URI")
+			}
+		} else if strings.Contains(opts.UrlStr+strings.Join(opts.AssumeRoles, ""), "{account}") {
+			return fmt.Errorf("{account} requires --accounts")
+		}
+	}
+
+	if opts.ImpersonateServiceAccount != "" {
+		gcp, ok := adapter.(gcpAdapter)
+		if !ok {
+			return fmt.Errorf("--impersonate-service-account is only supported for BigQuery, Cloud Storage, and Firestore")
+		}
 
+		serviceAccounts, err := parseServiceAccounts(opts.ImpersonateServiceAccount)
+		if err != nil {
+			return err
+		}
+		gcp.setImpersonate(serviceAccounts)
+	}
+
+	var prefixSamples []prefixSample
+	if len(opts.SamplePrefixes) > 0 {
+		if _, ok := adapter.(FileAdapter); !ok {
+			return fmt.Errorf("--sample-prefix is only supported for files, S3, and Cloud Storage")
+		}
+
+		prefixSamples, err = parsePrefixSamples(opts.SamplePrefixes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Forensic {
+		if _, ok := adapter.(FileAdapter); !ok {
+			return fmt.Errorf("--forensic is only supported for files, S3, and Cloud Storage")
+		}
+	}
+
+	var columnBudgetBytes int64
+	if opts.ColumnBudget != "" {
+		if _, ok := adapter.(DataStoreAdapter); !ok {
+			return fmt.Errorf("--column-budget is only supported for databases and data stores")
+		}
+
+		size, err := parseSize(opts.ColumnBudget)
+		if err != nil || size == 0 {
+			return fmt.Errorf("Invalid column budget: %s", opts.ColumnBudget)
+		}
+		columnBudgetBytes = size
+	}
+
+	var scopeConfig *scope
+	if opts.ScopeFile != "" {
+		config, err := loadScope(opts.ScopeFile)
+		if err != nil {
+			return err
+		}
+		scopeConfig = config
+	}
+
+	var ignoreConfig *ignoreList
+	if opts.IgnoreFile != "" {
+		config, err := loadIgnoreList(opts.IgnoreFile)
+		if err != nil {
+			return err
+		}
+		config.warnExpiry(os.Stderr, time.Now())
+		validNames := knownRuleNames()
+		for name := range makeValidNames(&matchConfig) {
+			validNames[name] = true
+		}
+		config.warnUnknownRules(os.Stderr, validNames)
+		ignoreConfig = config
+	}
+
+	if opts.Nice {
+		err := lowerPriority()
+		if err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	matchList, err := adapter.Scan(ScanOpts{
+		UrlStr:        opts.UrlStr,
+		ShowData:      opts.ShowData,
+		ShowAll:       opts.ShowAll,
+		Limit:         opts.Limit,
+		Debug:         opts.Debug,
+		Formatter:     formatter,
+		MatchConfig:   &matchConfig,
+		MaxFileSize:   maxFileSizeBytes,
+		Scope:         scopeConfig,
+		Ignore:        ignoreConfig,
+		Coverage:      scanCoverage,
+		TimeBudget:    timeBudgetDuration,
+		MaxValues:     opts.MaxValues,
+		Redactor:      valueRedactor,
+		ObjectTimeout: objectTimeout,
+		PrefixSamples: prefixSamples,
+		ColumnBudget:  columnBudgetBytes,
+		Forensic:      opts.Forensic,
+		State:         watermarks,
+	})
+
+	if err != nil {
+		return err
+	}
+	healthcheckMatches = len(matchList)
+
+	// only advance watermarks after a complete scan
+	if watermarks != nil {
+		err = watermarks.finishScan()
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.StatsFile != "" {
+		err = newScanStats(opts.UrlStr, labels, matchList, scanCoverage, time.Now().Sub(start)).save(opts.StatsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = formatter.Finish(os.Stdout)
 	if err != nil {
 		return err
 	}
 
+	if opts.NotifyUrl != "" || opts.NotifySlack != "" {
+		n := newNotification(opts.UrlStr, labels, matchList, opts.ShowAll)
+		if n.MatchesCount >= opts.NotifyThreshold {
+			err = notify(opts.NotifyUrl, opts.NotifySlack, n)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if matchList == nil {
 		return nil
 	}
 
 	if len(matchList) > 0 {
-		if showData {
-			fmt.Fprintln(os.Stderr, "Showing 50 unique values from each")
+		if opts.ShowData {
+			if valueRedactor != nil {
+				fmt.Fprintf(os.Stderr, "Showing %s from each (redacted)\n", pluralize(opts.MaxValues, "unique value"))
+			} else {
+				fmt.Fprintf(os.Stderr, "Showing %s from each\n", pluralize(opts.MaxValues, "unique value"))
+			}
 		} else {
 			fmt.Fprintln(os.Stderr, "\nUse --show-data to view data")
 		}
 
-		if !showAll {
+		if !opts.ShowAll {
 			showLowConfidenceMatchHelp(matchList)
 		}
+
+		if opts.Lineage {
+			printLineage(os.Stderr, findLineage(matchList, &matchConfig))
+		}
 	} else {
 		fmt.Fprintln(os.Stderr, "No sensitive data found")
 	}
 
+	// make gaps explicit so a clean result is not overstated
+	skippedCount := len(scanCoverage.Skipped())
+	if skippedCount > 0 && opts.Format != "coverage-json" {
+		fmt.Fprintf(os.Stderr, "Some data was not scanned (%s). Use --format coverage-json for details\n", pluralize(skippedCount, "skipped object"))
+	}
+
 	return nil
 }
 
+func newAdapter(urlStr string, query string) Adapter {
+	if urlStr == "-" || urlStr == "file://-" {
+		return &StdinAdapter{}
+	} else if strings.HasPrefix(urlStr, "file://") {
+		return &LocalFileAdapter{}
+	} else if strings.HasPrefix(urlStr, "s3://") {
+		return &S3Adapter{}
+	} else if strings.HasPrefix(urlStr, "gs://") {
+		return &GcsAdapter{}
+	} else if strings.HasPrefix(urlStr, "bigquery://") {
+		return &BigqueryAdapter{}
+	} else if strings.HasPrefix(urlStr, "firestore://") {
+		return &FirestoreAdapter{}
+	} else if strings.HasPrefix(urlStr, "mongodb://") {
+		return &MongodbAdapter{}
+	} else if strings.HasPrefix(urlStr, "redis://") {
+		return &RedisAdapter{}
+	} else if strings.HasPrefix(urlStr, "kafka://") {
+		return &KafkaAdapter{}
+	} else if strings.HasPrefix(urlStr, "kubernetes://") {
+		return &KubernetesAdapter{}
+	} else if strings.HasPrefix(urlStr, "cosmosdb://") {
+		return &CosmosdbAdapter{}
+	} else if strings.HasPrefix(urlStr, "elasticsearch+http://") || strings.HasPrefix(urlStr, "elasticsearch+https://") {
+		return &ElasticsearchAdapter{}
+	} else if strings.HasPrefix(urlStr, "opensearch+http://") || strings.HasPrefix(urlStr, "opensearch+https://") {
+		return &ElasticsearchAdapter{}
+	} else if strings.HasPrefix(urlStr, "okta://") {
+		return &OktaAdapter{}
+	} else if strings.HasPrefix(urlStr, "auth0://") {
+		return &Auth0Adapter{}
+	} else if strings.HasPrefix(urlStr, "hubspot://") {
+		return &HubspotAdapter{}
+	} else if strings.HasPrefix(urlStr, "mailchimp://") {
+		return &MailchimpAdapter{}
+	} else if strings.HasPrefix(urlStr, "twilio://") {
+		return &TwilioAdapter{}
+	} else if strings.HasPrefix(urlStr, "sendgrid://") {
+		return &SendgridAdapter{}
+	} else if strings.HasPrefix(urlStr, "mixpanel://") {
+		return &MixpanelAdapter{}
+	} else if strings.HasPrefix(urlStr, "amplitude://") {
+		return &AmplitudeAdapter{}
+	} else if strings.HasPrefix(urlStr, "pagerduty://") {
+		return &PagerdutyAdapter{}
+	} else if strings.HasPrefix(urlStr, "opsgenie://") {
+		return &OpsgenieAdapter{}
+	} else if strings.HasPrefix(urlStr, "rest://") {
+		return &RestAdapter{}
+	} else {
+		return &SqlAdapter{query: query}
+	}
+}
+
 func scanDataStore(adapter DataStoreAdapter, scanOpts ScanOpts) ([]ruleMatch, error) {
 	err := adapter.Init(scanOpts.UrlStr)
 	if err != nil {
@@ -121,6 +608,18 @@ func scanDataStore(adapter DataStoreAdapter, scanOpts ScanOpts) ([]ruleMatch, er
 		return nil, err
 	}
 
+	if scanOpts.Scope != nil {
+		included := []table{}
+		for _, table := range tables {
+			if scanOpts.Scope.includes(table) {
+				included = append(included, table)
+			} else {
+				scanOpts.Coverage.skip(table.displayName(), skipExcluded, "excluded by scope")
+			}
+		}
+		tables = included
+	}
+
 	if len(tables) > 0 {
 		limit := scanOpts.Limit
 
@@ -128,10 +627,42 @@ func scanDataStore(adapter DataStoreAdapter, scanOpts ScanOpts) ([]ruleMatch, er
 
 		matchList := []ruleMatch{}
 
+		// tables from an interrupted scan keep their matches
+		if scanOpts.State != nil {
+			remaining := []table{}
+			for _, table := range tables {
+				resumedMatchList, rows, ok := scanOpts.State.resumeTable(table.displayName())
+				if !ok {
+					remaining = append(remaining, table)
+					continue
+				}
+
+				scanOpts.Coverage.scan(table.displayName(), rows)
+				err := printMatchList(scanOpts.Formatter, resumedMatchList, scanOpts.ShowData, scanOpts.ShowAll, scanOpts.MaxValues, scanOpts.Redactor, adapter.RowName())
+				if err != nil {
+					return nil, err
+				}
+				matchList = append(matchList, resumedMatchList...)
+			}
+			tables = remaining
+		}
+
 		var g errgroup.Group
 		var appendMutex sync.Mutex
 		var queryMutex sync.Mutex
 
+		// queries run one at a time
+		var budget *timeBudget
+		var tableSizes map[string]int64
+		if scanOpts.TimeBudget > 0 {
+			tableSizes = sampleSizes(adapter, tables, limit)
+			sizes := make([]int64, 0, len(tables))
+			for _, table := range tables {
+				sizes = append(sizes, tableSizes[table.displayName()])
+			}
+			budget = newTimeBudget(scanOpts.TimeBudget, sizes, 1)
+		}
+
 		for _, table := range tables {
 			// important - do not remove
 			// https://go.dev/doc/faq#closures_and_goroutines
@@ -140,28 +671,133 @@ func scanDataStore(adapter DataStoreAdapter, scanOpts ScanOpts) ([]ruleMatch, er
 			g.Go(func() error {
 				start := time.Now()
 
+				policyAdapter, checkPolicy := adapter.(accessPolicyAdapter)
+				commentAdapter, checkComments := adapter.(columnCommentAdapter)
+
 				// limit to one query at a time
 				queryMutex.Lock()
-				tableData, err := adapter.FetchTableData(table, limit)
+
+				// tables that read rows one at a time are partially scanned when time runs out
+				var limits rowLimits
+				if budget != nil {
+					deadline, ok := budget.next(tableSizes[table.displayName()])
+					if !ok {
+						queryMutex.Unlock()
+						fmt.Fprintf(os.Stderr, "Skipped %s (time budget exhausted)\n", table.displayName())
+						scanOpts.Coverage.skip(table.displayName(), skipTimeBudget, "time budget exhausted")
+						return nil
+					}
+					limits.deadline = deadline
+				}
+				limitAdapter, checkLimits := adapter.(rowLimitAdapter)
+
+				var policy *accessPolicy
+				var comments map[string]string
+				var tableData *tableData
+				var err error
+				for attempt := 1; attempt <= tableAttempts; attempt++ {
+					if scanOpts.ColumnBudget > 0 {
+						limits.columns = newColumnBudget(scanOpts.ColumnBudget)
+					}
+					// queries are canceled when the table times out
+					err = withTimeout(scanOpts.ObjectTimeout, func(ctx context.Context) error {
+						var err error
+						if checkPolicy {
+							policy, err = policyAdapter.FetchAccessPolicy(ctx, table)
+						}
+						if err == nil && checkComments {
+							comments, err = commentAdapter.FetchColumnComments(ctx, table)
+						}
+						if err == nil {
+							if checkLimits {
+								tableData, err = limitAdapter.FetchTableDataWithLimits(ctx, table, limit, limits)
+							} else {
+								tableData, err = adapter.FetchTableData(ctx, table, limit)
+							}
+						}
+						return err
+					})
+					if err == nil || errors.Is(err, errTimeBudget) || errors.Is(err, errObjectTimeout) || (checkPolicy && policyAdapter.IsAccessDenied(err)) {
+						break
+					}
+				}
 				queryMutex.Unlock()
 
+				// report matches found before time ran out
+				partial := errors.Is(err, errTimeBudget)
+				if partial {
+					err = nil
+				}
+
 				if scanOpts.Debug {
 					duration := time.Now().Sub(start)
 					fmt.Fprintf(os.Stderr, "Scanned %s (%d ms)\n", table.displayName(), duration.Milliseconds())
 				}
 
 				if err != nil {
-					return err
+					if checkPolicy && policyAdapter.IsAccessDenied(err) {
+						reportAccessDenied(table, policy, scanOpts.Coverage)
+						return nil
+					}
+
+					// one locked or broken table should not stop the scan
+					if errors.Is(err, errObjectTimeout) {
+						reason := "timed out after " + scanOpts.ObjectTimeout.String()
+						fmt.Fprintf(os.Stderr, "Skipped %s (%s)\n", table.displayName(), reason)
+						scanOpts.Coverage.skip(table.displayName(), skipTimeout, reason)
+					} else {
+						fmt.Fprintf(os.Stderr, "Skipped %s (%s)\n", table.displayName(), err)
+						scanOpts.Coverage.skip(table.displayName(), skipFailed, err.Error())
+					}
+					return nil
 				}
 
+				if policy != nil {
+					policy.removeMaskedValues(tableData)
+					policy.report(table, scanOpts.Coverage)
+				}
+				var truncated map[string]bool
+				if limits.columns != nil {
+					if !checkLimits {
+						limits.columns.apply(tableData)
+					}
+					truncated = limits.columns.finish(table, tableData, scanOpts.Coverage)
+				}
+				if partial {
+					fmt.Fprintf(os.Stderr, "Partially scanned %s (time budget exhausted)\n", table.displayName())
+					scanOpts.Coverage.skip(table.displayName(), skipTimeBudget, "partially scanned before time budget was exhausted")
+				}
+				scanOpts.Coverage.scan(table.displayName(), tableData.RowCount)
+
 				matchFinder := NewMatchFinder(scanOpts.MatchConfig)
 				tableMatchList := matchFinder.CheckTableData(table, tableData)
 
-				err = printMatchList(scanOpts.Formatter, tableMatchList, scanOpts.ShowData, scanOpts.ShowAll, adapter.RowName())
+				if len(scanOpts.MatchConfig.Detectors) > 0 {
+					detectorMatchList, err := detectTableData(scanOpts.MatchConfig.Detectors, table, tableData)
+					if err != nil {
+						return err
+					}
+					tableMatchList = append(tableMatchList, detectorMatchList...)
+				}
+
+				if len(comments) > 0 {
+					tableMatchList = applyColumnHints(table, tableData, comments, tableMatchList, scanOpts.MatchConfig, scanOpts.Coverage)
+				}
+
+				markTruncated(tableMatchList, table, truncated)
+
+				tableMatchList, err = processMatches(tableMatchList, table.displayName(), scanOpts, adapter.RowName())
 				if err != nil {
 					return err
 				}
 
+				if scanOpts.State != nil {
+					err = scanOpts.State.completeTable(table.displayName(), tableData.RowCount, tableMatchList)
+					if err != nil {
+						return err
+					}
+				}
+
 				appendMutex.Lock()
 				matchList = append(matchList, tableMatchList...)
 				appendMutex.Unlock()
@@ -192,6 +828,14 @@ func scanFiles(adapter FileAdapter, scanOpts ScanOpts) ([]ruleMatch, error) {
 		return nil, err
 	}
 
+	if len(scanOpts.PrefixSamples) > 0 {
+		total := len(files)
+		files = sampleFiles(files, scanOpts.UrlStr, scanOpts.PrefixSamples, adapter.ObjectName(), scanOpts.Coverage)
+		if len(files) < total {
+			fmt.Fprintf(os.Stderr, "Skipped %s by prefix sampling\n", pluralize(total-len(files), adapter.ObjectName()))
+		}
+	}
+
 	if len(files) > 0 {
 		fmt.Fprintf(os.Stderr, "Found %s to scan...\n\n", pluralize(len(files), adapter.ObjectName()))
 
@@ -200,31 +844,94 @@ func scanFiles(adapter FileAdapter, scanOpts ScanOpts) ([]ruleMatch, error) {
 		var g errgroup.Group
 		var appendMutex sync.Mutex
 
-		g.SetLimit(20)
+		g.SetLimit(fileWorkers)
+
+		var budget *timeBudget
+		var sizes []int64
+		if scanOpts.TimeBudget > 0 {
+			sizes = budgetSizes(listedSizes(adapter, files))
+			budget = newTimeBudget(scanOpts.TimeBudget, sizes, fileWorkers)
+		}
 
-		for _, file := range files {
+		for i, file := range files {
 			// important - do not remove
 			// https://go.dev/doc/faq#closures_and_goroutines
-			file := file
+			i, file := i, file
 
 			g.Go(func() error {
 				start := time.Now()
 
 				matchFinder := NewMatchFinder(scanOpts.MatchConfig)
-				err := adapter.FindFileMatches(file, &matchFinder)
+				matchFinder.forensic = scanOpts.Forensic
+				if budget != nil {
+					deadline, ok := budget.next(sizes[i])
+					if !ok {
+						fmt.Fprintf(os.Stderr, "Skipped %s (time budget exhausted)\n", file)
+						scanOpts.Coverage.skip(file, skipTimeBudget, "time budget exhausted")
+						return nil
+					}
+					matchFinder.deadline = deadline
+				}
+
+				err := withTimeout(scanOpts.ObjectTimeout, func(ctx context.Context) error {
+					matchFinder.ctx = ctx
+					return findFileMatches(adapter, file, &matchFinder, scanOpts)
+				})
+
+				// report matches found before time ran out
+				truncated := errors.Is(err, errTimeBudget)
+				if truncated {
+					err = nil
+				}
 
 				if scanOpts.Debug {
 					duration := time.Now().Sub(start)
 					fmt.Fprintf(os.Stderr, "Scanned %s (%d ms)\n", file, duration.Milliseconds())
 				}
 
-				if err != nil {
-					return err
+				var skipErr skipError
+				if errors.As(err, &skipErr) {
+					fmt.Fprintf(os.Stderr, "Skipped %s (%s)\n", file, skipErr.reason)
+					scanOpts.Coverage.skip(file, skipErr.kind, skipErr.reason)
+					return nil
+				} else if errors.Is(err, os.ErrPermission) {
+					fmt.Fprintf(os.Stderr, "Skipped %s (permission denied)\n", file)
+					scanOpts.Coverage.skip(file, skipPermissionDenied, "permission denied")
+					return nil
+				} else if errors.Is(err, errObjectTimeout) {
+					reason := "timed out after " + scanOpts.ObjectTimeout.String()
+					fmt.Fprintf(os.Stderr, "Skipped %s (%s)\n", file, reason)
+					scanOpts.Coverage.skip(file, skipTimeout, reason)
+					return nil
+				} else if err != nil {
+					// one unreadable file should not stop the scan
+					fmt.Fprintf(os.Stderr, "Skipped %s (%s)\n", file, err)
+					scanOpts.Coverage.skip(file, skipFailed, err.Error())
+					return nil
 				}
 
+				if truncated {
+					fmt.Fprintf(os.Stderr, "Partially scanned %s (time budget exhausted)\n", file)
+					scanOpts.Coverage.skip(file, skipTimeBudget, "partially scanned before time budget was exhausted")
+				}
+				scanOpts.Coverage.scan(file, matchFinder.Count)
+
 				fileMatchList := matchFinder.CheckMatches(file, true)
+				fileMatchList = append(fileMatchList, matchFinder.CheckFields(file)...)
 
-				err = printMatchList(scanOpts.Formatter, fileMatchList, scanOpts.ShowData, scanOpts.ShowAll, "line")
+				if len(scanOpts.MatchConfig.Detectors) > 0 {
+					detectorMatchList, err := detectFileData(scanOpts.MatchConfig.Detectors, file, &matchFinder)
+					if err != nil {
+						return err
+					}
+					fileMatchList = append(fileMatchList, detectorMatchList...)
+				}
+
+				if accounts, ok := adapter.(accountAdapter); ok {
+					setAccount(fileMatchList, accounts.account(file))
+				}
+
+				fileMatchList, err = processMatches(fileMatchList, file, scanOpts, "line")
 				if err != nil {
 					return err
 				}
@@ -248,6 +955,23 @@ func scanFiles(adapter FileAdapter, scanOpts ScanOpts) ([]ruleMatch, error) {
 	}
 }
 
+// files scanned at once
+const fileWorkers = 20
+
+func findFileMatches(adapter FileAdapter, file string, matchFinder *MatchFinder, scanOpts ScanOpts) error {
+	if scanOpts.MaxFileSize > 0 {
+		size, err := adapter.FileSize(file)
+		if err != nil {
+			return err
+		}
+		if size > scanOpts.MaxFileSize {
+			return skipError{skipTooLarge, fmt.Sprintf("larger than %s", formatSize(scanOpts.MaxFileSize))}
+		}
+	}
+
+	return adapter.FindFileMatches(file, matchFinder)
+}
+
 func updateRules(matchConfig *MatchConfig, value string, except bool) error {
 	names := make(map[string]bool)
 	validNames := makeValidNames(matchConfig)
@@ -327,9 +1051,73 @@ func updateRules(matchConfig *MatchConfig, value string, except bool) error {
 	}
 	matchConfig.TokenRules = tokenRules
 
+	entropyRules := []entropyRule{}
+	for _, rule := range matchConfig.EntropyRules {
+		var keep bool
+		if except {
+			keep = !names[rule.Name]
+		} else {
+			keep = names[rule.Name]
+		}
+
+		if keep {
+			entropyRules = append(entropyRules, rule)
+		}
+	}
+	matchConfig.EntropyRules = entropyRules
+
+	formatRules := []formatRule{}
+	for _, rule := range matchConfig.FormatRules {
+		var keep bool
+		if except {
+			keep = !names[rule.Name]
+		} else {
+			keep = names[rule.Name]
+		}
+
+		if keep {
+			formatRules = append(formatRules, rule)
+		}
+	}
+	matchConfig.FormatRules = formatRules
+
 	return nil
 }
 
+func ruleDisplayName(matchConfig *MatchConfig, name string) string {
+	for _, rule := range matchConfig.RegexRules {
+		if rule.Name == name {
+			return rule.DisplayName
+		}
+	}
+	for _, rule := range matchConfig.NameRules {
+		if rule.Name == name {
+			return rule.DisplayName
+		}
+	}
+	for _, rule := range matchConfig.MultiNameRules {
+		if rule.Name == name {
+			return rule.DisplayName
+		}
+	}
+	for _, rule := range matchConfig.TokenRules {
+		if rule.Name == name {
+			return rule.DisplayName
+		}
+	}
+	for _, rule := range matchConfig.EntropyRules {
+		if rule.Name == name {
+			return rule.DisplayName
+		}
+	}
+	for _, rule := range matchConfig.FormatRules {
+		if rule.Name == name {
+			return rule.DisplayName
+		}
+	}
+	return name
+}
+
 func makeValidNames(matchConfig *MatchConfig) map[string]bool {
 	validNames := make(map[string]bool)
 	for _, rule := range matchConfig.RegexRules {
@@ -344,5 +1132,11 @@ func makeValidNames(matchConfig *MatchConfig) map[string]bool {
 	for _, rule := range matchConfig.TokenRules {
 		validNames[rule.Name] = true
 	}
+	for _, rule := range matchConfig.EntropyRules {
+		validNames[rule.Name] = true
+	}
+	for _, rule := range matchConfig.FormatRules {
+		validNames[rule.Name] = true
+	}
 	return validNames
 }
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MarkdownFormatter prints a summary table and a section for each table or file after the scan.
+type MarkdownFormatter struct {
+	matches  []reportMatch
+	coverage *coverage
+	mutex    sync.Mutex
+}
+
+func (f *MarkdownFormatter) setCoverage(source string, c *coverage) {
+	f.coverage = c
+}
+
+func (f *MarkdownFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.matches = append(f.matches, newReportMatch(match))
+	return nil
+}
+
+func (f *MarkdownFormatter) Finish(writer io.Writer) error {
+	matches := f.matches
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Source != matches[j].Source {
+			return matches[i].Source < matches[j].Source
+		}
+		return matches[i].Identifier < matches[j].Identifier
+	})
+
+	fmt.Fprintln(writer, "## Summary")
+	fmt.Fprintln(writer, "")
+
+	if len(matches) == 0 {
+		fmt.Fprintln(writer, "No sensitive data found")
+		f.printCoverage(writer)
+		return nil
+	}
+
+	fmt.Fprintln(writer, "| Identifier | Data | Confidence | Count |")
+	fmt.Fprintln(writer, "| --- | --- | --- | --- |")
+	for _, match := range matches {
+		fmt.Fprintf(writer, "| %s | %s | %s | %s |\n", markdownCell(match.Identifier), markdownCell(match.DisplayName), match.Confidence, markdownCount(match))
+	}
+
+	source := ""
+	for i, match := range matches {
+		if i == 0 || match.Source != source {
+			source = match.Source
+			heading := source
+			if heading == "" {
+				heading = match.Identifier
+			}
+			fmt.Fprintf(writer, "\n### %s\n", markdownCode(heading))
+		}
+
+		// markdown is not rendered in summary tags
+		summary := fmt.Sprintf("<code>%s</code>: %s", html.EscapeString(match.Identifier), html.EscapeString(match.DisplayName))
+		if match.MatchType == "name" {
+			summary += " (name match)"
+		} else if match.MatchType == "comment" {
+			summary += " (column comment)"
+		}
+
+		fmt.Fprintln(writer, "")
+		fmt.Fprintln(writer, "<details>")
+		fmt.Fprintf(writer, "<summary>%s</summary>\n\n", summary)
+		fmt.Fprintf(writer, "- Rule: %s\n", match.Rule)
+		fmt.Fprintf(writer, "- Match type: %s\n", match.MatchType)
+		fmt.Fprintf(writer, "- Confidence: %s\n", match.Confidence)
+		if match.MatchType == "value" {
+			fmt.Fprintf(writer, "- Count: %s\n", markdownCount(match))
+		}
+		if len(match.Brands) > 0 {
+			fmt.Fprintf(writer, "- Brands: %s\n", cardBrandSummary(match.Brands))
+		}
+		if match.Truncated {
+			fmt.Fprintln(writer, "- Values truncated at column budget")
+		}
+		if match.Account != "" {
+			fmt.Fprintf(writer, "- Account: %s\n", match.Account)
+		}
+		if len(match.Values) > 0 {
+			values := make([]string, len(match.Values))
+			for i, value := range match.Values {
+				values[i] = markdownCode(space.ReplaceAllString(value, " "))
+			}
+			fmt.Fprintf(writer, "- Values: %s\n", strings.Join(values, ", "))
+		}
+		fmt.Fprintln(writer, "")
+		fmt.Fprintln(writer, "</details>")
+	}
+
+	f.printCoverage(writer)
+	return nil
+}
+
+// lists what was not scanned so gaps are explicit
+func (f *MarkdownFormatter) printCoverage(writer io.Writer) {
+	skipped := f.coverage.Skipped()
+	if len(skipped) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer, "")
+	fmt.Fprintln(writer, "## Coverage")
+	fmt.Fprintln(writer, "")
+	fmt.Fprintf(writer, "Scanned %d, skipped %d\n", len(f.coverage.Scanned()), len(skipped))
+	fmt.Fprintln(writer, "")
+	fmt.Fprintln(writer, "| Identifier | Reason | Detail |")
+	fmt.Fprintln(writer, "| --- | --- | --- |")
+	for _, s := range skipped {
+		fmt.Fprintf(writer, "| %s | %s | %s |\n", markdownCell(s.Identifier), s.Reason, markdownCell(s.Detail))
+	}
+}
+
+func markdownCount(match reportMatch) string {
+	if match.MatchType == "name" {
+		return "-"
+	}
+	return pluralize(match.Count, match.CountName)
+}
+
+// newlines would end the table row or list item
+var markdownNewlines = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+var markdownEscapes = strings.NewReplacer("\\", "\\\\", "|", "\\|", "`", "\\`", "*", "\\*", "_", "\\_")
+
+// escapes characters with a meaning in markdown, so identifiers like user_email_2 are shown as is
+func markdownCell(str string) string {
+	return markdownEscapes.Replace(markdownNewlines.Replace(str))
+}
+
+// wraps in backticks, using more backticks than the longest run in the string
+// text in code spans is not escaped
+func markdownCode(str string) string {
+	str = markdownNewlines.Replace(str)
+	fence := "`"
+	for strings.Contains(str, fence) {
+		fence += "`"
+	}
+	if len(fence) > 1 {
+		return fence + " " + str + " " + fence
+	}
+	return fence + str + fence
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// marketingAdapter is implemented by adapters for marketing platforms,
+// which are also scanned for health and financial data
+type marketingAdapter interface {
+	marketingPlatform()
+}
+
+// conditions and treatments, like in tags or notes of contacts
+var healthTermRegex = regexp.MustCompile(`(?i)\b(?:diabetes|diabetic|cancer|chemotherapy|hiv|aids|hepatitis|pregnant|pregnancy|fertility|ivf|abortion|miscarriage|depression|bipolar|schizophrenia|ptsd|adhd|autism|dementia|alzheimer'?s|parkinson'?s|epilepsy|asthma|copd|insulin|dialysis|rehab|addiction|antidepressants?|diagnosis|diagnosed|prescription|medication)\b`)
+
+var ibanRegex = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z\d]{4}){2,7}(?: ?[A-Z\d]{1,3})?\b`)
+
+// account and routing numbers, with optional separators
+var bankAccountRegex = regexp.MustCompile(`\A[A-Z]{0,4}[\d -]{6,34}\z`)
+
+// ISO 13616, with the country code and check digits moved to the end and letters as numbers
+func validIban(v string) bool {
+	iban := strings.ReplaceAll(v, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(c-'A') + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+	return n.Mod(n, big.NewInt(97)).Int64() == 1
+}
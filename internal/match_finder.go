@@ -1,13 +1,23 @@
 package internal
 
 import (
+	"context"
+	"encoding/json"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type tableData struct {
 	ColumnNames  []string
 	ColumnValues [][]string
+	// database types, if known
+	ColumnTypes []string
+	// rows, documents, or keys sampled
+	RowCount int
 }
 
 type MatchConfig struct {
@@ -15,6 +25,9 @@ type MatchConfig struct {
 	NameRules      []nameRule
 	MultiNameRules []multiNameRule
 	TokenRules     []tokenRule
+	EntropyRules   []entropyRule
+	FormatRules    []formatRule
+	Detectors      []Detector
 	MinCount       int
 }
 
@@ -24,50 +37,160 @@ func NewMatchConfig() MatchConfig {
 		NameRules:      nameRules,
 		MultiNameRules: multiNameRules,
 		TokenRules:     tokenRules,
+		EntropyRules:   entropyRules,
+		FormatRules:    formatRules,
 		MinCount:       1,
 	}
 }
 
+// names of all built-in rules, including opt-in rules like card data,
+// so comments and ignore entries for them aren't reported as unknown
+func knownRuleNames() map[string]bool {
+	matchConfig := NewMatchConfig()
+	matchConfig.RegexRules = append(append(append(append([]regexRule{}, regexRules...), cardDataRegexRules...), obfuscatedRegexRules...), marketingRegexRules...)
+	matchConfig.NameRules = append(append(append([]nameRule{}, nameRules...), cardDataNameRules...), marketingNameRules...)
+	return makeValidNames(&matchConfig)
+}
+
 type MatchFinder struct {
-	MatchedValues [][]MatchLine
-	TokenValues   [][]MatchLine
-	Count         int
-	matchConfig   *MatchConfig
+	MatchedValues  [][]MatchLine
+	TokenValues    [][]MatchLine
+	EntropyValues  [][]MatchLine
+	FormatValues   [][]string
+	DetectorValues []string
+	Count          int
+	matchConfig    *MatchConfig
+	// fields of structured records, like JSON lines, by path
+	Fields map[string]*fieldMatchFinder
+	// stop scanning a file after this time, if set
+	deadline time.Time
+	// common words of each language, if any rules are for a language
+	languageWords map[string]int
+	// also scan deleted data, like SQLite freelist pages
+	forensic bool
+	// stop scanning a file when canceled, like when it times out
+	ctx context.Context
 }
 
+type fieldMatchFinder struct {
+	MatchFinder
+	// sample of values for name matches
+	values    []string
+	lastIndex int
+	// geometry, like GeoJSON points
+	coordinates bool
+	// name for name rules, if different from the path
+	name string
+}
+
+// max values of each field kept for name matches
+const fieldValueLimit = 1000
+
 type MatchLine struct {
 	LineIndex int
 	Line      string
+	// bytes at the start that were also scanned with the previous chunk of a long line
+	Overlap int
 }
 
 var tokenizer = regexp.MustCompile(`\W+`)
 
 func NewMatchFinder(matchConfig *MatchConfig) MatchFinder {
 	return MatchFinder{
-		make([][]MatchLine, len(matchConfig.RegexRules)),
-		make([][]MatchLine, len(matchConfig.TokenRules)),
-		0,
-		matchConfig,
+		MatchedValues: make([][]MatchLine, len(matchConfig.RegexRules)),
+		TokenValues:   make([][]MatchLine, len(matchConfig.TokenRules)),
+		EntropyValues: make([][]MatchLine, len(matchConfig.EntropyRules)),
+		FormatValues:  make([][]string, len(matchConfig.FormatRules)),
+		matchConfig:   matchConfig,
+		languageWords: newLanguageWords(matchConfig),
+		ctx:           context.Background(),
 	}
 }
 
 // fast check for matches
 // extract values and index in a later step if needed (if --show-data is passed)
 func (a *MatchFinder) Scan(v string, index int) {
+	a.scanChunk("", v, index)
+}
+
+// the overlap is the end of the previous chunk, so values that span chunks are found
+// matches and words that end in the overlap were already found
+func (a *MatchFinder) scanChunk(overlap string, chunk string, index int) {
+	overlap = normalizeValue(overlap)
+	v := overlap + normalizeValue(chunk)
+
 	for i, rule := range a.matchConfig.RegexRules {
-		if rule.Regex.MatchString(v) {
-			a.MatchedValues[i] = append(a.MatchedValues[i], MatchLine{index, v})
+		if rule.matchesAfter(v, len(overlap)) {
+			a.MatchedValues[i] = append(a.MatchedValues[i], MatchLine{index, v, len(overlap)})
 		}
 	}
 
+	// words that are only in the overlap were already checked
 	if len(a.matchConfig.TokenRules) > 0 {
-		tokens := tokenizer.Split(strings.ToLower(v), -1)
+		tv := v[overlapStart(v, len(overlap), isTokenChar):]
+		tokens := tokenizer.Split(strings.ToLower(tv), -1)
 		for i, rule := range a.matchConfig.TokenRules {
 			if anyMatches(rule, tokens) {
-				a.TokenValues[i] = append(a.TokenValues[i], MatchLine{index, v})
+				a.TokenValues[i] = append(a.TokenValues[i], MatchLine{index, tv, 0})
 			}
 		}
 	}
+
+	if len(a.matchConfig.EntropyRules) > 0 {
+		ev := v[overlapStart(v, len(overlap), isEntropyChar):]
+		for i, rule := range a.matchConfig.EntropyRules {
+			if len(highEntropyStrings(rule, ev)) > 0 {
+				a.EntropyValues[i] = append(a.EntropyValues[i], MatchLine{index, ev, 0})
+			}
+		}
+	}
+
+	if a.languageWords != nil {
+		countLanguageWords(a.languageWords, v[overlapStart(v, len(overlap), unicode.IsLetter):])
+	}
+
+	if len(a.matchConfig.Detectors) > 0 && len(a.DetectorValues) < detectorLineLimit {
+		a.DetectorValues = append(a.DetectorValues, v)
+	}
+}
+
+// the start of the word that spans the end of the overlap, or the end of the overlap
+// a word that ends in the overlap was already seen in full with the previous chunk
+func overlapStart(v string, start int, inWord func(rune) bool) int {
+	if start == 0 || start >= len(v) {
+		return start
+	}
+	if r, _ := utf8.DecodeRuneInString(v[start:]); !inWord(r) {
+		return start
+	}
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(v[:start])
+		if !inWord(r) {
+			break
+		}
+		start -= size
+	}
+	return start
+}
+
+// matches \w, which the tokenizer splits on the inverse of
+func isTokenChar(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// matches the characters of entropyCandidate
+func isEntropyChar(r rune) bool {
+	return isTokenChar(r) || r == '+' || r == '/' || r == '=' || r == '-'
+}
+
+// checks the start of a file, including files in archives
+func (a *MatchFinder) ScanHead(head []byte) {
+	for i, rule := range a.matchConfig.FormatRules {
+		format := rule.Detect(head)
+		if format != "" {
+			a.FormatValues[i] = append(a.FormatValues[i], format)
+		}
+	}
 }
 
 func anyMatches(rule tokenRule, values []string) bool {
@@ -89,7 +212,155 @@ func (a *MatchFinder) ScanValues(values []string) {
 func (a *MatchFinder) Clear() {
 	a.MatchedValues = make([][]MatchLine, len(a.matchConfig.RegexRules))
 	a.TokenValues = make([][]MatchLine, len(a.matchConfig.TokenRules))
+	a.EntropyValues = make([][]MatchLine, len(a.matchConfig.EntropyRules))
+	a.FormatValues = make([][]string, len(a.matchConfig.FormatRules))
+	a.DetectorValues = nil
 	a.Count = 0
+	a.Fields = nil
+	a.languageWords = newLanguageWords(a.matchConfig)
+}
+
+// scans the values of a structured record by path
+// the record counts as a single line
+func (a *MatchFinder) ScanRecord(value interface{}, path string) {
+	switch typedVal := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range typedVal {
+			a.ScanRecord(nested, path+"."+key)
+		}
+	case []interface{}:
+		for _, nested := range typedVal {
+			a.ScanRecord(nested, path+"[*]")
+		}
+	case string:
+		a.scanField(path, typedVal)
+	case json.Number:
+		a.scanField(path, typedVal.String())
+	}
+}
+
+func (a *MatchFinder) scanField(path string, v string) {
+	a.scanNamedField(path, "", v)
+}
+
+func (a *MatchFinder) scanNamedField(path string, name string, v string) {
+	field := a.field(path)
+	if name != "" {
+		field.name = name
+	}
+
+	// count records, not array elements
+	if field.lastIndex != a.Count {
+		field.Count += 1
+		field.lastIndex = a.Count
+	}
+
+	field.Scan(v, a.Count)
+	if len(field.values) < fieldValueLimit {
+		field.values = append(field.values, v)
+	}
+}
+
+// scans the values of a field from separate rows, like a column of an embedded database
+func (a *MatchFinder) scanFieldValues(path string, values []string) {
+	field := a.field(path)
+	field.ScanValues(values)
+	for _, v := range values {
+		if len(field.values) == fieldValueLimit {
+			break
+		}
+		field.values = append(field.values, v)
+	}
+}
+
+// scans the coordinates of a geometry, like x y pairs
+func (a *MatchFinder) scanCoordinates(path string, values []string) {
+	field := a.field(path)
+	field.coordinates = true
+
+	if field.lastIndex != a.Count {
+		field.Count += 1
+		field.lastIndex = a.Count
+	}
+
+	for _, v := range values {
+		if len(field.values) == fieldValueLimit {
+			break
+		}
+		field.values = append(field.values, v)
+	}
+}
+
+func (a *MatchFinder) field(path string) *fieldMatchFinder {
+	if a.Fields == nil {
+		a.Fields = make(map[string]*fieldMatchFinder)
+	}
+
+	field, ok := a.Fields[path]
+	if !ok {
+		field = &fieldMatchFinder{MatchFinder: NewMatchFinder(a.matchConfig), lastIndex: -1}
+		a.Fields[path] = field
+	}
+	return field
+}
+
+// checks matches and names of fields
+func (a *MatchFinder) CheckFields(identifier string) []ruleMatch {
+	matchList := []ruleMatch{}
+
+	for _, path := range a.fieldPaths() {
+		field := a.Fields[path]
+		fieldIdentifier := identifier + " " + path
+
+		name := strings.Replace(path, "[*]", "", -1)
+		if field.name != "" {
+			name = field.name
+		}
+
+		fieldMatchList := field.CheckMatches(fieldIdentifier, true)
+		fieldMatchList = field.checkName(fieldIdentifier, name, field.values, fieldMatchList)
+
+		// values of fields with a known meaning, like the family name of a contact, are high confidence
+		if field.name != "" {
+			rule := matchNameRule(field.name, a.matchConfig.NameRules)
+			for j, match := range fieldMatchList {
+				if match.RuleName == rule.Name {
+					fieldMatchList[j].Confidence = "high"
+					fieldMatchList[j].rowCount = 0
+				}
+			}
+		}
+		if field.coordinates {
+			fieldMatchList = append(fieldMatchList, field.checkCoordinates(fieldIdentifier)...)
+		}
+		matchList = append(matchList, fieldMatchList...)
+	}
+
+	return matchList
+}
+
+// coordinates use the location rule, so it can be excluded with --except
+func (a *fieldMatchFinder) checkCoordinates(identifier string) []ruleMatch {
+	matchList := []ruleMatch{}
+	if a.Count < a.matchConfig.MinCount {
+		return matchList
+	}
+
+	for _, rule := range a.matchConfig.MultiNameRules {
+		if rule.Name == "location" {
+			matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: "high", Identifier: identifier, MatchedData: a.values, LineCount: a.Count, MatchType: "value"})
+		}
+	}
+	return matchList
+}
+
+func (a *MatchFinder) fieldPaths() []string {
+	paths := make([]string, 0, len(a.Fields))
+	for path := range a.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
 }
 
 func (a *MatchFinder) CheckMatches(colIdentifier string, onlyValues bool) []ruleMatch {
@@ -97,67 +368,86 @@ func (a *MatchFinder) CheckMatches(colIdentifier string, onlyValues bool) []rule
 
 	matchedValues := a.MatchedValues
 	count := a.Count
+	language := a.language()
 
 	for i, rule := range a.matchConfig.RegexRules {
-		matchedData := []string{}
-		for _, v := range matchedValues[i] {
-			matchedData = append(matchedData, v.Line)
+		if rule.Language != "" && rule.Language != language {
+			continue
 		}
 
+		matchLines := matchedValues[i]
+
 		if rule.Name == "email" {
 			// filter out false positives with URL credentials
-			newMatchedData := matchedData
-			matchedData = []string{}
-			for _, v := range newMatchedData {
+			newMatchLines := matchLines
+			matchLines = []MatchLine{}
+			for _, v := range newMatchLines {
 				// replace urls and check for email match again
 				// TODO preserve offset
-				v2 := urlPassword.ReplaceAllString(v, "[FILTERED]")
-				if rule.Regex.MatchString(v2) {
-					matchedData = append(matchedData, v)
+				v2 := urlPassword.ReplaceAllString(v.Line, "[FILTERED]")
+				if rule.matchesAfter(v2, v.Overlap) {
+					matchLines = append(matchLines, v)
 				}
 			}
 		}
 
-		if len(matchedData) >= a.matchConfig.MinCount {
+		matchedData := []string{}
+		lineIndexes := make(map[int]bool)
+		for _, v := range matchLines {
+			matchedData = append(matchedData, v.Line)
+			lineIndexes[v.LineIndex] = true
+		}
+
+		// long lines are scanned in chunks with the same index
+		lineCount := len(lineIndexes)
+
+		if lineCount >= a.matchConfig.MinCount {
 			confidence := rule.Confidence
+			rowCount := 0
 			// variable confidence
 			if confidence == "" {
-				if float64(len(matchedData))/float64(count) > 0.5 {
-					confidence = "high"
-				} else {
-					confidence = "low"
-				}
+				confidence = shareConfidence(lineCount, count)
+				rowCount = count
 			}
 
-			lineCount := len(matchedData)
+			var brands map[string]int
+			if rule.Name == "credit_card" {
+				cards := []string{}
+				for _, v := range matchLines {
+					cards = append(cards, rule.findAllAfter(v.Line, v.Overlap)...)
+				}
+				brands = cardBrandCounts(cards)
+			}
 
 			if onlyValues {
 				var matchedValues []string
-				for _, v := range matchedData {
-					v3 := rule.Regex.FindAllString(v, -1)
+				for _, v := range matchLines {
+					v3 := rule.findAllAfter(v.Line, v.Overlap)
 					matchedValues = append(matchedValues, v3...)
 				}
 				matchedData = matchedValues
 			}
 
-			matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: confidence, Identifier: colIdentifier, MatchedData: matchedData, LineCount: lineCount, MatchType: "value"})
+			matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: confidence, Identifier: colIdentifier, MatchedData: matchedData, LineCount: lineCount, MatchType: "value", Brands: brands, rowCount: rowCount})
 		}
 	}
 
 	for i, rule := range a.matchConfig.TokenRules {
 		matchedData := []string{}
+		lineIndexes := make(map[int]bool)
 		for _, v := range a.TokenValues[i] {
 			matchedData = append(matchedData, v.Line)
+			lineIndexes[v.LineIndex] = true
 		}
 
-		if len(matchedData) >= a.matchConfig.MinCount {
+		lineCount := len(lineIndexes)
+
+		if lineCount >= a.matchConfig.MinCount {
 			confidence := "low"
-			if float64(len(matchedData))/float64(count) > 0.1 && len(unique(matchedData)) >= 10 {
+			if float64(lineCount)/float64(count) > 0.1 && len(unique(matchedData)) >= 10 {
 				confidence = "high"
 			}
 
-			lineCount := len(matchedData)
-
 			if onlyValues {
 				var matchedValues []string
 				for _, v := range matchedData {
@@ -176,12 +466,64 @@ func (a *MatchFinder) CheckMatches(colIdentifier string, onlyValues bool) []rule
 		}
 	}
 
+	for i, rule := range a.matchConfig.EntropyRules {
+		matchedData := []string{}
+		lineIndexes := make(map[int]bool)
+		for _, v := range a.EntropyValues[i] {
+			matchedData = append(matchedData, v.Line)
+			lineIndexes[v.LineIndex] = true
+		}
+
+		lineCount := len(lineIndexes)
+
+		if lineCount >= a.matchConfig.MinCount {
+			if onlyValues {
+				var matchedValues []string
+				for _, v := range matchedData {
+					matchedValues = append(matchedValues, highEntropyStrings(rule, v)...)
+				}
+				matchedData = matchedValues
+			}
+
+			// high confidence requires a corroborating column name
+			matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: "low", Identifier: colIdentifier, MatchedData: matchedData, LineCount: lineCount, MatchType: "value"})
+		}
+	}
+
+	for i, rule := range a.matchConfig.FormatRules {
+		formats := a.FormatValues[i]
+		if len(formats) > 0 {
+			matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: rule.Confidence, Identifier: colIdentifier, MatchedData: formats, LineCount: len(formats), MatchType: "format"})
+		}
+	}
+
+	for i := range matchList {
+		matchList[i].Language = language
+	}
+
 	return matchList
 }
 
+// rules without a fixed confidence are high confidence when most rows match
+func shareConfidence(lineCount int, rowCount int) string {
+	if float64(lineCount)/float64(rowCount) > 0.5 {
+		return "high"
+	}
+	return "low"
+}
+
+// the dominant language of free text, if any rules are for a language
+func (a *MatchFinder) language() string {
+	if a.languageWords == nil {
+		return ""
+	}
+	return dominantLanguage(a.languageWords)
+}
+
 func (a *MatchFinder) CheckTableData(table table, tableData *tableData) []ruleMatch {
 	tableMatchList := []ruleMatch{}
 
+	tableData = expandJsonColumns(tableData)
 	columnNames := tableData.ColumnNames
 	columnValues := tableData.ColumnValues
 
@@ -196,42 +538,82 @@ func (a *MatchFinder) CheckTableData(table table, tableData *tableData) []ruleMa
 			colIdentifier = table.displayName() + "." + col
 		}
 
-		a.Clear()
-		a.ScanValues(values)
-		matchList := a.CheckMatches(colIdentifier, false)
-
-		// only check name if no matches
-		if len(matchList) == 0 {
-			name := strings.Replace(strings.ToLower(col), "_", "", -1)
-
-			// check last part for nested data
-			parts := strings.Split(name, ".")
-			name = parts[len(parts)-1]
-
-			rule := matchNameRule(name, a.matchConfig.NameRules)
-			if rule.Name != "" {
-				matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: "medium", Identifier: colIdentifier, MatchedData: values, MatchType: "name"})
-			}
+		var matchList []ruleMatch
+		switch tableData.columnKind(i) {
+		case integerColumn:
+			matchList = a.checkIntegers(colIdentifier, values)
+		case skipColumn:
+			matchList = []ruleMatch{}
+		default:
+			a.Clear()
+			a.ScanValues(values)
+			matchList = a.CheckMatches(colIdentifier, false)
+			matchList = a.checkHintedIntegers(colIdentifier, col, values, matchList)
 		}
+		matchList = a.checkName(colIdentifier, col, values, matchList)
 
 		tableMatchList = append(tableMatchList, matchList...)
 	}
 
 	for _, rule := range a.matchConfig.MultiNameRules {
-		var latCol string
-		var lonCol string
+		cols := make([]string, len(rule.ColumnNames))
 		for _, col := range columnNames {
-			if stringInSlice(col, rule.ColumnNames[0]) {
-				latCol = col
-			} else if stringInSlice(col, rule.ColumnNames[1]) {
-				lonCol = col
+			name := normalizeColumnName(col)
+			for j, names := range rule.ColumnNames {
+				if cols[j] == "" && stringInSlice(name, names) {
+					cols[j] = col
+					break
+				}
 			}
 		}
-		if latCol != "" && lonCol != "" {
+		if !stringInSlice("", cols) {
+			confidence := rule.Confidence
+			if confidence == "" {
+				confidence = "medium"
+			}
 			// TODO show data
-			tableMatchList = append(tableMatchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: "medium", Identifier: table.displayName() + "." + latCol + "+" + lonCol, MatchType: "name"})
+			tableMatchList = append(tableMatchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: confidence, Identifier: table.displayName() + "." + strings.Join(cols, "+"), MatchType: "name"})
 		}
 	}
 
 	return tableMatchList
 }
+
+// applies name rules to a column or field after checking its values
+func (a *MatchFinder) checkName(colIdentifier string, col string, values []string, matchList []ruleMatch) []ruleMatch {
+	valueMatches := 0
+	for j, match := range matchList {
+		rule := findEntropyRule(match.RuleName, a.matchConfig.EntropyRules)
+		if rule == nil {
+			valueMatches++
+			// a corroborating name makes value matches more likely to be real
+			if regexRule := findRegexRule(match.RuleName, a.matchConfig.RegexRules); regexRule != nil && match.MatchType == "value" && matchesColumnHint(col, regexRule.ColumnHints) {
+				matchList[j].Confidence = "high"
+				matchList[j].rowCount = 0
+			}
+		} else if matchesColumnHint(col, rule.ColumnHints) {
+			matchList[j].Confidence = "high"
+		}
+	}
+
+	// only check name if no matches
+	// possible secrets can be more specific with a name match
+	if valueMatches == 0 {
+		name := strings.Replace(strings.ToLower(col), "_", "", -1)
+
+		// check last part for nested data
+		parts := strings.Split(name, ".")
+		name = parts[len(parts)-1]
+
+		rule := matchNameRule(name, a.matchConfig.NameRules)
+		if rule.Name != "" && rule.matchesValues(values) {
+			confidence := rule.Confidence
+			if confidence == "" {
+				confidence = "medium"
+			}
+			matchList = append(matchList, ruleMatch{RuleName: rule.Name, DisplayName: rule.DisplayName, Confidence: confidence, Identifier: colIdentifier, MatchedData: values, MatchType: "name"})
+		}
+	}
+
+	return matchList
+}
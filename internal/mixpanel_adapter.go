@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MixpanelAdapter scans event properties with the Mixpanel raw export API
+type MixpanelAdapter struct {
+	endpoint  string
+	projectId string
+	username  string
+	secret    string
+	events    *analyticsEvents
+}
+
+// days of events to export
+const mixpanelExportDays = 7
+
+func (a *MixpanelAdapter) TableName() string {
+	return "event type"
+}
+
+func (a *MixpanelAdapter) RowName() string {
+	return "event"
+}
+
+func (a *MixpanelAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *MixpanelAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	a.projectId = u.Host
+	if a.projectId == "" {
+		return errors.New("no project ID specified")
+	}
+
+	// service accounts
+	a.username = u.User.Username()
+	if a.username == "" {
+		a.username = os.Getenv("MIXPANEL_USERNAME")
+	}
+	var ok bool
+	a.secret, ok = u.User.Password()
+	if !ok {
+		a.secret = os.Getenv("MIXPANEL_SECRET")
+	}
+	if a.username == "" || a.secret == "" {
+		return errors.New("no service account specified")
+	}
+
+	a.endpoint = "https://data.mixpanel.com"
+	if u.Query().Get("region") == "eu" {
+		a.endpoint = "https://data-eu.mixpanel.com"
+	}
+	return nil
+}
+
+func (a *MixpanelAdapter) FetchTables() ([]table, error) {
+	err := a.fetchEvents()
+	if err != nil {
+		return nil, err
+	}
+	return a.events.tables(), nil
+}
+
+func (a MixpanelAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	return a.events.tableData(table.Name, limit), nil
+}
+
+// https://developer.mixpanel.com/reference/raw-event-export
+func (a *MixpanelAdapter) fetchEvents() error {
+	now := time.Now().UTC()
+	query := url.Values{}
+	query.Set("project_id", a.projectId)
+	query.Set("from_date", now.AddDate(0, 0, -mixpanelExportDays).Format("2006-01-02"))
+	query.Set("to_date", now.Format("2006-01-02"))
+	query.Set("limit", strconv.Itoa(analyticsMaxEvents))
+
+	req, err := http.NewRequest("GET", a.endpoint+"/api/2.0/export?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(a.username, a.secret)
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("[%s] %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	a.events = newAnalyticsEvents()
+	return a.events.addLines(resp.Body, parseMixpanelEvent)
+}
+
+func parseMixpanelEvent(line []byte) (string, map[string]interface{}, bool) {
+	object, ok := parseJsonLine(line)
+	if !ok {
+		return "", nil, false
+	}
+	name, ok := object["event"].(string)
+	if !ok {
+		return "", nil, false
+	}
+	properties, _ := object["properties"].(map[string]interface{})
+	return name, properties, true
+}
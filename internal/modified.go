@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// modifiedFilterAdapter is implemented by file adapters that know when objects were last modified
+// so monitoring runs can focus on recently written objects
+type modifiedFilterAdapter interface {
+	setModifiedFilter(filter modifiedFilter)
+}
+
+type modifiedFilter struct {
+	// skip objects modified before this time, if set
+	since       time.Time
+	newestFirst bool
+}
+
+type modifiedObject struct {
+	name     string
+	modified time.Time
+}
+
+func (f modifiedFilter) apply(objects []modifiedObject) []string {
+	if f.newestFirst {
+		sort.SliceStable(objects, func(i, j int) bool {
+			return objects[i].modified.After(objects[j].modified)
+		})
+	}
+
+	files := []string{}
+	for _, object := range objects {
+		if f.since.IsZero() || !object.modified.Before(f.since) {
+			files = append(files, object.name)
+		}
+	}
+	return files
+}
+
+var daysRegex = regexp.MustCompile(`^(\d+)d$`)
+
+// ages, like 30d or 12h, or times, like 2024-01-01
+func parseModifiedSince(value string, now time.Time) (time.Time, error) {
+	if match := daysRegex.FindStringSubmatch(value); match != nil {
+		days, err := strconv.Atoi(match[1])
+		if err == nil {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err == nil && duration > 0 {
+		return now.Add(-duration), nil
+	}
+
+	t, err := parseTime(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid modified since: %s", value)
+	}
+	return t, nil
+}
@@ -72,10 +72,10 @@ func (a MongodbAdapter) FetchTables() ([]table, error) {
 	return tables, nil
 }
 
-func (a MongodbAdapter) FetchTableData(table table, limit int) (*tableData, error) {
+func (a MongodbAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
 	collection := a.DB.Collection(table.Name)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// https://www.mongodb.com/docs/manual/reference/operator/aggregation/sample/
@@ -96,6 +96,7 @@ func (a MongodbAdapter) FetchTableData(table table, limit int) (*tableData, erro
 
 	columnValues := make([][]string, 0)
 
+	count := 0
 	for cur.Next(ctx) {
 		var result bson.D
 		err := cur.Decode(&result)
@@ -104,6 +105,7 @@ func (a MongodbAdapter) FetchTableData(table table, limit int) (*tableData, erro
 		}
 
 		keyMap, columnValues = scanObject(result, "", keyMap, columnValues)
+		count++
 	}
 	if err := cur.Err(); err != nil {
 		return nil, err
@@ -114,7 +116,7 @@ func (a MongodbAdapter) FetchTableData(table table, limit int) (*tableData, erro
 		columnNames[i] = key
 	}
 
-	return &tableData{columnNames, columnValues}, nil
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: count}, nil
 }
 
 func scanObject(object bson.D, prefix string, keyMap map[string]int, columnValues [][]string) (map[string]int, [][]string) {
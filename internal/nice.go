@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// lowest scheduling priority
+const niceValue = 19
+
+// readLimiter throttles reads across all files to a number of bytes per second
+type readLimiter struct {
+	rate  int64
+	start time.Time
+	bytes int64
+	mutex sync.Mutex
+}
+
+func newReadLimiter(rate int64) *readLimiter {
+	return &readLimiter{rate: rate, start: time.Now()}
+}
+
+func (l *readLimiter) wait(n int) {
+	l.mutex.Lock()
+	now := time.Now()
+	due := l.start.Add(time.Duration(float64(l.bytes) / float64(l.rate) * float64(time.Second)))
+	// don't allow a burst after being idle
+	if now.Sub(due) > time.Second {
+		l.start = now
+		l.bytes = 0
+	}
+	l.bytes += int64(n)
+	due = l.start.Add(time.Duration(float64(l.bytes) / float64(l.rate) * float64(time.Second)))
+	l.mutex.Unlock()
+
+	time.Sleep(time.Until(due))
+}
+
+type throttledReader struct {
+	reader  io.Reader
+	limiter *readLimiter
+}
+
+func (r throttledReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
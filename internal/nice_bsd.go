@@ -0,0 +1,12 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package internal
+
+import (
+	"syscall"
+)
+
+// IO priority is only supported on Linux
+func lowerPriority() error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, niceValue)
+}
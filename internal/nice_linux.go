@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// priorities are per thread on Linux, so update existing threads
+// and new threads inherit from the thread that creates them
+func lowerPriority() error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+
+		err = syscall.Setpriority(syscall.PRIO_PROCESS, tid, niceValue)
+		if err != nil {
+			return err
+		}
+
+		// only read disk when it is otherwise idle
+		_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprioClassIdle<<ioprioClassShift)
+		if errno != 0 {
+			return errno
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package internal
+
+import (
+	"fmt"
+)
+
+func lowerPriority() error {
+	return fmt.Errorf("--nice is not supported on this platform")
+}
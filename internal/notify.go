@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// notifications never include matched data, only where it was found
+
+type notification struct {
+	Source       string              `json:"source"`
+	Labels       map[string]string   `json:"labels,omitempty"`
+	GeneratedAt  time.Time           `json:"generated_at"`
+	MatchesCount int                 `json:"matches_count"`
+	Matches      []notificationMatch `json:"matches"`
+}
+
+type notificationMatch struct {
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+	MatchType  string `json:"match_type"`
+	Confidence string `json:"confidence"`
+	Count      int    `json:"count"`
+}
+
+// max matches listed in Slack messages
+const slackMatchLimit = 20
+
+func newNotification(source string, labels map[string]string, matchList []ruleMatch, showAll bool) notification {
+	matches := []notificationMatch{}
+	for _, match := range matchList {
+		if showAll || match.Confidence != "low" {
+			matches = append(matches, notificationMatch{
+				Identifier: match.Identifier,
+				Name:       match.RuleName,
+				MatchType:  match.MatchType,
+				Confidence: match.Confidence,
+				Count:      match.LineCount,
+			})
+		}
+	}
+
+	// matches arrive in parallel
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Identifier < matches[j].Identifier
+	})
+
+	return notification{
+		Source:       redactUrl(source),
+		Labels:       labels,
+		GeneratedAt:  time.Now().UTC(),
+		MatchesCount: len(matches),
+		Matches:      matches,
+	}
+}
+
+func notify(notifyUrl string, notifySlack string, n notification) error {
+	if notifyUrl != "" {
+		err := postJSON(notifyUrl, n)
+		if err != nil {
+			return fmt.Errorf("Error sending notification: %s", err)
+		}
+	}
+
+	if notifySlack != "" {
+		err := postJSON(notifySlack, map[string]string{"text": slackText(n)})
+		if err != nil {
+			return fmt.Errorf("Error sending Slack notification: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func slackText(n notification) string {
+	lines := []string{fmt.Sprintf("pdscan found %s in %s", pluralize(n.MatchesCount, "match"), n.Source)}
+	for i, match := range n.Matches {
+		if i == slackMatchLimit {
+			lines = append(lines, fmt.Sprintf("and %d more", n.MatchesCount-slackMatchLimit))
+			break
+		}
+
+		var description string
+		if match.MatchType == "name" {
+			description = "possible " + match.Name + " (name match)"
+		} else {
+			description = fmt.Sprintf("%s (%d, %s confidence)", match.Name, match.Count, match.Confidence)
+		}
+		lines = append(lines, fmt.Sprintf("• `%s`: %s", match.Identifier, description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func postJSON(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// like [at], (at), or " at " for @, and [dot], (dot), or " dot " for .
+var obfuscatedAt = `(?:\s*[\[({<]\s*at\s*[\])}>]\s*|\s+at\s+|\s*[@＠﹫]\s*)`
+var obfuscatedDot = `(?:\s*[\[({<]\s*dot\s*[\])}>]\s*|\s+dot\s+|[.．。])`
+
+// letters include other scripts, so emails with homoglyphs, like a Cyrillic о, are found
+var obfuscatedEmailRegex = regexp.MustCompile(`(?i)[\p{L}\p{N}_%+-]+(?:` + obfuscatedDot + `[\p{L}\p{N}_%+-]+)*` + obfuscatedAt + `[\p{L}\p{N}-]+(?:` + obfuscatedDot + `[\p{L}\p{N}-]+)+`)
+
+// words or runs of digits, including fullwidth digits, like ５
+var obfuscatedDigit = `(?:\b(?:zero|oh|one|two|three|four|five|six|seven|eight|nine)\b|[\d０-９]+)`
+
+// like five five five-1234 or (555) one two three 4567
+var obfuscatedPhoneRegex = regexp.MustCompile(`(?i)\(?` + obfuscatedDigit + `(?:\)?[\s.－-]*\(?` + obfuscatedDigit + `)+`)
+
+var obfuscatedPhoneToken = regexp.MustCompile(`[a-z]+|\d+`)
+
+var obfuscatedDigits = map[string]string{
+	"zero": "0", "oh": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+}
+
+var obfuscatedWord = regexp.MustCompile(`(?i)\s*[\[({<]\s*(?:at|dot)\s*[\])}>]\s*|\s+(?:at|dot)\s+|\s*[@＠﹫]\s*|[．。]`)
+
+var obfuscatedEmailFormat = regexp.MustCompile(`\A[a-z\d_%+-]+(?:\.[a-z\d_%+-]+)*@[a-z\d-]+(?:\.[a-z\d-]+)*\.[a-z]{2,}\z`)
+
+// Latin lookalikes from Cyrillic and Greek
+var homoglyphs = strings.NewReplacer(
+	"а", "a", "в", "b", "е", "e", "к", "k", "м", "m", "н", "h", "о", "o", "р", "p", "с", "c", "т", "t", "у", "y", "х", "x",
+	"і", "i", "ј", "j", "ѕ", "s", "ԁ", "d", "ԛ", "q", "ԝ", "w", "ӏ", "l",
+	"α", "a", "ε", "e", "ι", "i", "κ", "k", "ν", "v", "ο", "o", "ρ", "p", "τ", "t", "υ", "u", "χ", "x",
+)
+
+// fullwidth forms, like ＠ and ５, and homoglyphs are replaced with ASCII
+func foldHomoglyphs(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r >= 0xFF01 && r <= 0xFF5E {
+			return r - 0xFEE0
+		}
+		return r
+	}, strings.ToLower(v))
+	return homoglyphs.Replace(v)
+}
+
+// only obfuscated emails are reported, since others are found by the email rule
+func validObfuscatedEmail(v string) bool {
+	email := obfuscatedWord.ReplaceAllStringFunc(v, func(s string) string {
+		if strings.Contains(strings.ToLower(s), "dot") || strings.ContainsAny(s, "．。") {
+			return "."
+		}
+		return "@"
+	})
+	email = foldHomoglyphs(email)
+	return email != strings.ToLower(v) && obfuscatedEmailFormat.MatchString(email)
+}
+
+// only numbers with words or fullwidth digits are reported, since others are found by the phone rule
+func validObfuscatedPhone(v string) bool {
+	number := foldHomoglyphs(v)
+	obfuscated := strings.IndexFunc(number, func(r rune) bool { return r >= 'a' && r <= 'z' }) != -1 || number != strings.ToLower(v)
+	if !obfuscated {
+		return false
+	}
+
+	var digits strings.Builder
+	for _, token := range obfuscatedPhoneToken.FindAllString(number, -1) {
+		if d, ok := obfuscatedDigits[token]; ok {
+			digits.WriteString(d)
+		} else if token[0] >= '0' && token[0] <= '9' {
+			digits.WriteString(token)
+		}
+	}
+	return digits.Len() >= 7 && digits.Len() <= 15
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// errObjectTimeout is returned when a table or file takes longer than --timeout-per-object
+var errObjectTimeout = errors.New("timed out")
+
+// attempts to fetch a table before it's skipped, so brief errors like dropped connections don't leave gaps
+const tableAttempts = 2
+
+// fetch is passed a context that is canceled after the timeout, which stops queries and file reads
+// so nothing keeps running after the scan moves on to the next table or file
+func withTimeout(timeout time.Duration, fetch func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fetch(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := fetch(ctx)
+	if err != nil && (ctx.Err() != nil || errors.Is(err, os.ErrDeadlineExceeded)) {
+		return errObjectTimeout
+	}
+	return err
+}
+
+// stops blocked reads, like from a pipe, when the context times out
+// regular files do not support deadlines, but their reads do not block
+func setReadDeadline(ctx context.Context, f *os.File) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && f.SetReadDeadline(deadline) == nil
+}
+
+// for inherited pipes without deadlines, like stdin
+// a blocked read is left behind after a timeout, but it only writes to its own buffer
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	type readResult struct {
+		data []byte
+		err  error
+	}
+
+	done := make(chan readResult, 1)
+	go func() {
+		buf := make([]byte, len(p))
+		n, err := r.reader.Read(buf)
+		done <- readResult{buf[:n], err}
+	}()
+
+	select {
+	case result := <-done:
+		return copy(p, result.data), result.err
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
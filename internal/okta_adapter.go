@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"errors"
+	"net/url"
+	"os"
+)
+
+// OktaAdapter scans custom user profile attributes and app profiles with the Okta management API
+// standard attributes, like email and mobilePhone, are expected to have personal data, so they are skipped
+type OktaAdapter struct {
+	RestAdapter
+}
+
+// https://developer.okta.com/docs/reference/api/users/#default-profile-properties
+var oktaProfileAttributes = []string{
+	"login", "email", "secondEmail", "firstName", "lastName", "middleName", "honorificPrefix", "honorificSuffix",
+	"title", "displayName", "nickName", "profileUrl", "primaryPhone", "mobilePhone", "streetAddress", "city",
+	"state", "zipCode", "countryCode", "postalAddress", "preferredLanguage", "locale", "timezone", "userType",
+	"employeeNumber", "costCenter", "organization", "division", "department", "managerId", "manager",
+}
+
+func (a *OktaAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *OktaAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	token, ok := u.User.Password()
+	if !ok {
+		token = os.Getenv("OKTA_API_TOKEN")
+	}
+	if token == "" {
+		return errors.New("no API token specified")
+	}
+
+	return a.init(newOktaConfig("https://"+u.Host, token))
+}
+
+func newOktaConfig(endpoint string, token string) *restConfig {
+	exclude := make([]string, len(oktaProfileAttributes))
+	for i, attribute := range oktaProfileAttributes {
+		exclude[i] = "profile." + attribute
+	}
+
+	// Okta pages with Link headers
+	pagination := restPagination{Type: "link", SizeParam: "limit", Size: 200}
+
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "header"
+	config.Auth.Header = "Authorization"
+	config.Auth.Token = "SSWS " + token
+	config.Resources = []restResource{
+		{Name: "users", Path: "/api/v1/users", Fields: []string{"profile"}, Exclude: exclude, Pagination: pagination},
+		{Name: "apps", Path: "/api/v1/apps", Fields: []string{"profile"}, Pagination: pagination},
+	}
+	return config
+}
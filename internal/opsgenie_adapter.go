@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"errors"
+	"net/url"
+	"os"
+)
+
+// OpsgenieAdapter scans alerts and incidents with the Opsgenie REST API
+type OpsgenieAdapter struct {
+	RestAdapter
+}
+
+func (a *OpsgenieAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *OpsgenieAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	key, ok := u.User.Password()
+	if !ok {
+		key = os.Getenv("OPSGENIE_API_KEY")
+	}
+	if key == "" {
+		return errors.New("no API key specified")
+	}
+
+	endpoint := "https://api.opsgenie.com"
+	if u.Query().Get("region") == "eu" {
+		endpoint = "https://api.eu.opsgenie.com"
+	}
+	return a.init(newOpsgenieConfig(endpoint, key))
+}
+
+// alert lists don't include descriptions or details, which need a request for each alert
+func newOpsgenieConfig(endpoint string, key string) *restConfig {
+	pagination := restPagination{Type: "next", SizeParam: "limit", Size: 100, Next: "paging.next"}
+
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "header"
+	config.Auth.Header = "Authorization"
+	config.Auth.Token = "GenieKey " + key
+	config.Resources = []restResource{
+		{Name: "alerts", Path: "/v2/alerts", Params: map[string]string{"sort": "createdAt", "order": "desc"}, Items: "data", Fields: []string{"message", "tags"}, Pagination: pagination},
+		{Name: "incidents", Path: "/v1/incidents", Params: map[string]string{"sort": "createdAt", "order": "desc"}, Items: "data", Fields: []string{"message", "description", "tags", "extraProperties"}, Pagination: pagination},
+	}
+	return config
+}
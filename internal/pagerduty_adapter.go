@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"errors"
+	"net/url"
+	"os"
+)
+
+// PagerdutyAdapter scans incidents, notes, and alert payloads with the PagerDuty REST API
+type PagerdutyAdapter struct {
+	RestAdapter
+}
+
+func (a *PagerdutyAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *PagerdutyAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	token, ok := u.User.Password()
+	if !ok {
+		token = os.Getenv("PAGERDUTY_TOKEN")
+	}
+	if token == "" {
+		return errors.New("no API token specified")
+	}
+
+	return a.init(newPagerdutyConfig("https://api.pagerduty.com", token))
+}
+
+// notes and alert payloads are in the channels of log entries, like annotate and trigger entries
+func newPagerdutyConfig(endpoint string, token string) *restConfig {
+	pagination := restPagination{Type: "offset", SizeParam: "limit", Size: 100, Next: "more"}
+
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "header"
+	config.Auth.Header = "Authorization"
+	config.Auth.Token = "Token token=" + token
+	config.Headers = map[string]string{"Accept": "application/vnd.pagerduty+json;version=2"}
+	config.Resources = []restResource{
+		{Name: "incidents", Path: "/incidents", Params: map[string]string{"date_range": "all", "sort_by": "created_at:desc"}, Items: "incidents", Fields: []string{"title", "description"}, Pagination: pagination},
+		{Name: "log_entries", Path: "/log_entries", Params: map[string]string{"include[]": "channels"}, Items: "log_entries", Fields: []string{"channel"}, Pagination: pagination},
+	}
+	return config
+}
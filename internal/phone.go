@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+var phoneNumber = `(?:\+\d{1,2}\s)?\(?\d{3}\)?[\s+.-]\d{3}[\s+.-]\d{4}`
+
+// like x1234, ext. 22, or extension 22
+var phoneExtension = `(?:\s*,?\s*(?i:x|ext\.?|extension)\s*\d{1,6}\b)?`
+
+// toll-free numbers with letters, like 1-800-FLOWERS or 1-800-GOT-JUNK
+var phoneVanity = `(?:1[\s.-])?\(?8(?:00|33|44|55|66|77|88)\)?[\s.-](?:[A-Z]{3}[\s.-]?[A-Z0-9]{4}|[A-Z0-9]{3}[\s.-]?[A-Z]{4})[A-Z]{0,4}`
+
+// numbers can include digits before or after with a dot, so they can be rejected by validPhoneFormat
+var phoneRegex = regexp.MustCompile(`(?:\d+\.)?\b` + phoneNumber + `\b(?:\.\d+)?` + phoneExtension + `|(?:\+|%2B)[1-9]\d{6,14}\b` + phoneExtension + `|\b` + phoneVanity + `\b` + phoneExtension)
+
+// part of a longer dotted sequence, like a version (2.555.555.5555) or an IP address
+// a leading 1 is a country code, like 1.800.555.0199
+var phoneDotted = regexp.MustCompile(`^(?:\d{2,}|[02-9])\.` + phoneNumber + `|^` + phoneNumber + `\.\d`)
+
+func validPhoneFormat(v string) bool {
+	return !phoneDotted.MatchString(v)
+}
+
+func parsePhoneRegions(value string) ([]string, error) {
+	supported := phonenumbers.GetSupportedRegions()
+
+	regions := []string{}
+	for _, region := range strings.Split(value, ",") {
+		region = strings.ToUpper(strings.TrimSpace(region))
+		if !supported[region] {
+			return nil, fmt.Errorf("Invalid phone region: %s", region)
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// extensions and letters of vanity numbers are handled by the parser
+// checks the number is assigned in one of the regions, so values like 555-123-4567 are not reported
+// numbers with a country code are checked for that country
+func validPhone(regions []string) func(string) bool {
+	return func(v string) bool {
+		v = strings.Replace(v, "%2B", "+", 1)
+		for _, region := range regions {
+			number, err := phonenumbers.Parse(v, region)
+			if err == nil && phonenumbers.IsValidNumber(number) {
+				return true
+			}
+		}
+		return false
+	}
+}
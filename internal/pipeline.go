@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"github.com/jcschmidt31/pdscan/pipeline"
+)
+
+// values are normalized before rules are checked
+func normalizeValue(v string) string {
+	for _, h := range pipeline.Registered() {
+		if h.Normalize != nil {
+			v = h.Normalize(v)
+		}
+	}
+	return v
+}
+
+// candidate matches from rules and detectors go through the rest of the pipeline
+// validate → score → suppress → emit
+func processMatches(matchList []ruleMatch, source string, scanOpts ScanOpts, rowStr string) ([]ruleMatch, error) {
+	hooks := pipeline.Registered()
+
+	setSource(matchList, source)
+	matchList = validateMatches(matchList, hooks)
+	scoreMatches(matchList, hooks)
+	matchList = suppressMatches(matchList, hooks, scanOpts.Ignore, scanOpts.MatchConfig)
+
+	err := printMatchList(scanOpts.Formatter, matchList, scanOpts.ShowData, scanOpts.ShowAll, scanOpts.MaxValues, scanOpts.Redactor, rowStr)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hooks {
+		if h.Emit != nil {
+			for _, match := range matchList {
+				h.Emit(emitMatch(match, scanOpts.Redactor))
+			}
+		}
+	}
+	return matchList, nil
+}
+
+func validateMatches(matchList []ruleMatch, hooks []pipeline.Hooks) []ruleMatch {
+	for _, h := range hooks {
+		if h.Validate == nil {
+			continue
+		}
+
+		validated := []ruleMatch{}
+		for _, match := range matchList {
+			match, ok := filterValues(match, func(v string) bool { return h.Validate(match.RuleName, v) })
+			if ok {
+				validated = append(validated, match)
+			}
+		}
+		matchList = validated
+	}
+	return matchList
+}
+
+func scoreMatches(matchList []ruleMatch, hooks []pipeline.Hooks) {
+	for _, h := range hooks {
+		if h.Score == nil {
+			continue
+		}
+
+		for i, match := range matchList {
+			confidence := h.Score(pipelineMatch(match))
+			if confidence != "" {
+				matchList[i].Confidence = confidence
+				matchList[i].rowCount = 0
+			}
+		}
+	}
+}
+
+// hooks run before the ignore file
+func suppressMatches(matchList []ruleMatch, hooks []pipeline.Hooks, ignore *ignoreList, matchConfig *MatchConfig) []ruleMatch {
+	for _, h := range hooks {
+		if h.Suppress == nil {
+			continue
+		}
+
+		kept := []ruleMatch{}
+		for _, match := range matchList {
+			if !h.Suppress(pipelineMatch(match)) {
+				kept = append(kept, match)
+			}
+		}
+		matchList = kept
+	}
+
+	if ignore != nil {
+		matchList = ignore.filter(matchList, matchConfig)
+	}
+	return matchList
+}
+
+// keeps the values of a value match that pass, and reports if any are left
+// name matches are about the column, not its values
+func filterValues(match ruleMatch, keep func(string) bool) (ruleMatch, bool) {
+	if match.MatchType != "value" || len(match.MatchedData) == 0 {
+		return match, true
+	}
+
+	matchedData := []string{}
+	for _, v := range match.MatchedData {
+		if keep(v) {
+			matchedData = append(matchedData, v)
+		}
+	}
+	if len(matchedData) == 0 {
+		return match, false
+	}
+	if len(matchedData) < match.LineCount {
+		match.LineCount = len(matchedData)
+		// filtered values no longer count toward the share of rows
+		if match.rowCount > 0 {
+			match.Confidence = shareConfidence(match.LineCount, match.rowCount)
+		}
+	}
+	match.MatchedData = matchedData
+	return match, true
+}
+
+// values are redacted like in the output, so hooks like alerts don't get raw data
+func emitMatch(match ruleMatch, redactor *redactor) pipeline.Match {
+	m := pipelineMatch(match)
+	if redactor != nil {
+		for i, v := range m.Values {
+			m.Values[i] = redactor.redact(match.RuleName, v)
+		}
+	}
+	return m
+}
+
+// copies values so hooks can't change matches
+func pipelineMatch(match ruleMatch) pipeline.Match {
+	return pipeline.Match{
+		Rule:       match.RuleName,
+		Confidence: match.Confidence,
+		Source:     match.Source,
+		Identifier: match.Identifier,
+		Type:       match.MatchType,
+		Values:     append([]string{}, match.MatchedData...),
+		LineCount:  match.LineCount,
+	}
+}
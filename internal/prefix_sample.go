@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// prefixSample scans a percent of the files or objects under a prefix
+// so effort goes where the risk is, like all of exports/ but 1% of logs/
+type prefixSample struct {
+	prefix  string
+	percent float64
+}
+
+func parsePrefixSamples(values []string) ([]prefixSample, error) {
+	samples := []prefixSample{}
+	for _, value := range values {
+		i := strings.LastIndex(value, "=")
+		if i == -1 || !strings.HasSuffix(value, "%") {
+			return nil, fmt.Errorf("Invalid sample prefix: %s", value)
+		}
+		percent, err := strconv.ParseFloat(value[i+1:len(value)-1], 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("Invalid sample prefix: %s", value)
+		}
+		samples = append(samples, prefixSample{prefix: value[:i], percent: percent})
+	}
+	return samples, nil
+}
+
+// the longest matching prefix applies, and files without one are all scanned
+// prefixes are relative to the directory or prefix being scanned
+func samplePercent(samples []prefixSample, path string) (prefixSample, bool) {
+	var match prefixSample
+	found := false
+	for _, sample := range samples {
+		if strings.HasPrefix(path, sample.prefix) && (!found || len(sample.prefix) > len(match.prefix)) {
+			match = sample
+			found = true
+		}
+	}
+	return match, found
+}
+
+// objects are chosen at random, so repeated scans cover different objects
+// skipped objects are reported once for each prefix, since there can be millions
+func sampleFiles(files []string, urlStr string, samples []prefixSample, objectName string, c *coverage) []string {
+	root := strings.TrimPrefix(urlStr, "file://")
+
+	sampled := []string{}
+	skipped := make(map[string]int)
+	for _, file := range files {
+		path := strings.TrimPrefix(strings.TrimPrefix(file, root), "/")
+		sample, found := samplePercent(samples, path)
+		if !found || rand.Float64()*100 < sample.percent {
+			sampled = append(sampled, file)
+		} else {
+			skipped[sample.prefix]++
+		}
+	}
+
+	for _, sample := range samples {
+		if count, ok := skipped[sample.prefix]; ok {
+			identifier := strings.TrimSuffix(root, "/") + "/" + sample.prefix
+			c.skip(identifier, skipNotSampled, fmt.Sprintf("%s not sampled (%s%% of %s)", pluralize(count, objectName), strconv.FormatFloat(sample.percent, 'f', -1, 64), sample.prefix))
+			delete(skipped, sample.prefix)
+		}
+	}
+	return sampled
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ways to redact values shown with --show-data
+var redactModes = []string{"partial", "hash", "full"}
+
+// rules where the last digits help verify matches, like the last 4 of a card
+var lastDigitsRules = []string{"credit_card", "ssn", "phone"}
+
+// digits kept with partial redaction
+const redactKeepDigits = 4
+
+// redactor redacts values so reports show what was found without leaking it
+type redactor struct {
+	mode string
+	// hashes are keyed per scan so they can't be reversed with a dictionary
+	// but the same value has the same hash within a report
+	key []byte
+}
+
+func newRedactor(mode string) (*redactor, error) {
+	if !stringInSlice(mode, redactModes) {
+		return nil, fmt.Errorf("Invalid redact mode: %s\nValid modes are %s", mode, strings.Join(redactModes, ", "))
+	}
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	return &redactor{mode: mode, key: key}, nil
+}
+
+func (r *redactor) redact(rule string, value string) string {
+	switch r.mode {
+	case "full":
+		return "[REDACTED]"
+	case "partial":
+		if stringInSlice(rule, lastDigitsRules) {
+			return maskDigits(value, redactKeepDigits)
+		}
+	}
+	return r.hash(value)
+}
+
+func (r *redactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return "hash:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// keeps separators so the format is still visible, like ***-**-6789
+// letters are masked since column values can include other text
+func maskDigits(value string, keep int) string {
+	runes := []rune(value)
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsDigit(runes[i]) && keep > 0 {
+			keep--
+		} else if unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
@@ -46,10 +46,10 @@ func (a RedisAdapter) FetchTables() ([]table, error) {
 	return []table{{Schema: "", Name: ""}}, nil
 }
 
-func (a RedisAdapter) FetchTableData(table table, limit int) (*tableData, error) {
+func (a RedisAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
 	rdb := a.DB
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	keyMap := make(map[string]int)
@@ -123,5 +123,5 @@ func (a RedisAdapter) FetchTableData(table table, limit int) (*tableData, error)
 		columnNames[i] = key
 	}
 
-	return &tableData{columnNames, columnValues}, nil
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: len(keyMap)}, nil
 }
@@ -0,0 +1,441 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// retries for rate limited requests
+const restRetries = 3
+
+// RestAdapter scans paginated JSON APIs described by a config file,
+// for SaaS tools without a dedicated adapter
+type RestAdapter struct {
+	config *restConfig
+	client *restClient
+}
+
+type restConfig struct {
+	Endpoint string `yaml:"endpoint"`
+	Auth     struct {
+		// bearer, basic, or header
+		Type     string `yaml:"type"`
+		Header   string `yaml:"header"`
+		Token    string `yaml:"token"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+	} `yaml:"auth"`
+	Headers map[string]string `yaml:"headers"`
+	// minimum time between requests, like 500ms
+	Throttle  string         `yaml:"throttle"`
+	Resources []restResource `yaml:"resources"`
+}
+
+type restResource struct {
+	Name   string            `yaml:"name"`
+	Path   string            `yaml:"path"`
+	Params map[string]string `yaml:"params"`
+	// JSON path to the records in the response, or the response itself if empty
+	Items string `yaml:"items"`
+	// JSON paths to scan in each record, or all fields if empty
+	Fields []string `yaml:"fields"`
+	// JSON paths to skip in each record, like fields expected to have personal data
+	Exclude    []string       `yaml:"exclude"`
+	Pagination restPagination `yaml:"pagination"`
+}
+
+type restPagination struct {
+	// page, offset, cursor, next, or link
+	Type      string `yaml:"type"`
+	Param     string `yaml:"param"`
+	SizeParam string `yaml:"size_param"`
+	Size      int    `yaml:"size"`
+	// first page number, 1 by default
+	Start *int `yaml:"start"`
+	// JSON path to the next cursor or URL in the response,
+	// or for page and offset, to a boolean that's false on the last page
+	Next string `yaml:"next"`
+}
+
+var restPaginationParams = map[string]string{"page": "page", "offset": "offset", "cursor": "cursor"}
+
+type restClient struct {
+	http      *http.Client
+	header    http.Header
+	throttle  time.Duration
+	mutex     sync.Mutex
+	lastFetch time.Time
+}
+
+func (a *RestAdapter) TableName() string {
+	return "resource"
+}
+
+func (a *RestAdapter) RowName() string {
+	return "record"
+}
+
+func (a *RestAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *RestAdapter) Init(urlStr string) error {
+	config, err := loadRestConfig(urlStr[7:])
+	if err != nil {
+		return err
+	}
+	return a.init(config)
+}
+
+func loadRestConfig(filename string) (*restConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var config restConfig
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid REST config %s: %s", filename, err)
+	}
+	return &config, nil
+}
+
+// credentials can reference environment variables, like ${API_TOKEN}, so they stay out of config files
+func (a *RestAdapter) init(config *restConfig) error {
+	if config.Endpoint == "" {
+		return errors.New("no endpoint specified")
+	}
+	if len(config.Resources) == 0 {
+		return errors.New("no resources specified")
+	}
+	for _, resource := range config.Resources {
+		if resource.Name == "" {
+			return errors.New("resources must have a name")
+		}
+		switch resource.Pagination.Type {
+		case "", "page", "offset", "link":
+		case "cursor", "next":
+			if resource.Pagination.Next == "" {
+				return fmt.Errorf("%s pagination for %s requires next", resource.Pagination.Type, resource.Name)
+			}
+		default:
+			return fmt.Errorf("Invalid pagination for %s: %s\nValid types are cursor, link, next, offset, page", resource.Name, resource.Pagination.Type)
+		}
+	}
+
+	client := &restClient{http: &http.Client{Timeout: 30 * time.Second}, header: make(http.Header)}
+
+	if config.Throttle != "" {
+		throttle, err := time.ParseDuration(config.Throttle)
+		if err != nil || throttle < 0 {
+			return fmt.Errorf("Invalid throttle: %s", config.Throttle)
+		}
+		client.throttle = throttle
+	}
+
+	auth := config.Auth
+	switch auth.Type {
+	case "":
+	case "bearer":
+		client.header.Set("Authorization", "Bearer "+os.ExpandEnv(auth.Token))
+	case "basic":
+		req := &http.Request{Header: make(http.Header)}
+		req.SetBasicAuth(os.ExpandEnv(auth.Username), os.ExpandEnv(auth.Password))
+		client.header.Set("Authorization", req.Header.Get("Authorization"))
+	case "header":
+		if auth.Header == "" {
+			return errors.New("header auth requires header")
+		}
+		client.header.Set(auth.Header, os.ExpandEnv(auth.Token))
+	default:
+		return fmt.Errorf("Invalid auth type: %s\nValid types are basic, bearer, header", auth.Type)
+	}
+	client.header.Set("Accept", "application/json")
+	for k, v := range config.Headers {
+		client.header.Set(k, os.ExpandEnv(v))
+	}
+
+	a.config = config
+	a.client = client
+	return nil
+}
+
+func (a RestAdapter) FetchTables() ([]table, error) {
+	tables := []table{}
+	for _, resource := range a.config.Resources {
+		tables = append(tables, table{Schema: "", Name: resource.Name})
+	}
+	return tables, nil
+}
+
+func (a RestAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	var resource restResource
+	for _, r := range a.config.Resources {
+		if r.Name == table.Name {
+			resource = r
+		}
+	}
+	pagination := resource.Pagination
+
+	u, err := url.Parse(strings.TrimSuffix(a.config.Endpoint, "/") + resource.Path)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	for k, v := range resource.Params {
+		query.Set(k, v)
+	}
+
+	param := pagination.Param
+	if param == "" {
+		param = restPaginationParams[pagination.Type]
+	}
+
+	keyMap := make(map[string]int)
+
+	columnValues := make([][]string, 0)
+
+	// TODO randomize
+	count := 0
+	page := 1
+	if pagination.Start != nil {
+		page = *pagination.Start
+	}
+	// page size stays the same so page numbers line up
+	if pagination.SizeParam != "" {
+		size := limit
+		if pagination.Size > 0 && pagination.Size < size {
+			size = pagination.Size
+		}
+		query.Set(pagination.SizeParam, strconv.Itoa(size))
+	}
+
+	for count < limit {
+		switch pagination.Type {
+		case "page":
+			query.Set(param, strconv.Itoa(page))
+		case "offset":
+			query.Set(param, strconv.Itoa(count))
+		}
+		u.RawQuery = query.Encode()
+
+		var result interface{}
+		resp, err := a.client.get(ctx, u.String(), &result)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := restRecords(result, resource.Items)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > limit-count {
+			records = records[:limit-count]
+		}
+
+		for _, record := range records {
+			if len(resource.Fields) > 0 {
+				selected := make(map[string]interface{})
+				for _, field := range resource.Fields {
+					if v := restLookup(record, field); v != nil {
+						selected[strings.TrimPrefix(field, "$.")] = v
+					}
+				}
+				record = selected
+			}
+			for _, field := range resource.Exclude {
+				restDelete(record, field)
+			}
+			keyMap, columnValues = scanSource(record, "", keyMap, columnValues)
+		}
+		count += len(records)
+
+		if len(records) == 0 {
+			break
+		}
+
+		// an empty next cursor or URL means the last page
+		next := ""
+		switch pagination.Type {
+		case "page", "offset":
+			next = "more"
+			if pagination.Next != "" && restLookup(result, pagination.Next) != true {
+				next = ""
+			}
+		case "cursor", "next":
+			switch v := restLookup(result, pagination.Next).(type) {
+			case string:
+				next = v
+			case float64:
+				next = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		case "link":
+			next = restNextLink(resp.Header.Get("Link"))
+		}
+		if next == "" {
+			break
+		}
+
+		switch pagination.Type {
+		case "page":
+			page++
+		case "cursor":
+			query.Set(param, next)
+		case "next", "link":
+			nextUrl, err := u.Parse(next)
+			if err != nil {
+				return nil, err
+			}
+			u = nextUrl
+			query = u.Query()
+		}
+	}
+
+	columnNames := make([]string, len(keyMap))
+	for key, i := range keyMap {
+		columnNames[i] = key
+	}
+
+	return &tableData{ColumnNames: columnNames, ColumnValues: columnValues, RowCount: count}, nil
+}
+
+func (c *restClient) get(ctx context.Context, urlStr string, result interface{}) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		c.wait()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = c.header.Clone()
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// wait for the rate limit to reset, like the Retry-After header says
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < restRetries {
+			resp.Body.Close()
+			delay := time.Second << attempt
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				delay = time.Duration(seconds) * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+			return nil, fmt.Errorf("[%s] %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(result)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the response body: %s", err)
+		}
+
+		return resp, nil
+	}
+}
+
+func (c *restClient) wait() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.throttle > 0 {
+		if wait := c.throttle - time.Since(c.lastFetch); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	c.lastFetch = time.Now()
+}
+
+// responses can be a list of records, a single record, or an object with the list at a path
+func restRecords(result interface{}, path string) ([]map[string]interface{}, error) {
+	value := restLookup(result, path)
+
+	records := []map[string]interface{}{}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				records = append(records, record)
+			}
+		}
+	case map[string]interface{}:
+		records = append(records, v)
+	case nil:
+	default:
+		return nil, fmt.Errorf("no records at %s", path)
+	}
+	return records, nil
+}
+
+// JSON paths are dot-separated keys and array indexes, like data.users or $.results.0.profile
+func restLookup(value interface{}, path string) interface{} {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return value
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func restDelete(record map[string]interface{}, path string) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if i := strings.LastIndexByte(path, '.'); i != -1 {
+		if parent, ok := restLookup(record, path[:i]).(map[string]interface{}); ok {
+			delete(parent, path[i+1:])
+		}
+	} else {
+		delete(record, path)
+	}
+}
+
+// https://datatracker.ietf.org/doc/html/rfc8288
+func restNextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		for _, param := range parts[1:] {
+			param = strings.ReplaceAll(strings.TrimSpace(param), " ", "")
+			if param == `rel="next"` || param == "rel=next" {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
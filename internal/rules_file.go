@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesFile defines custom rules
+type rulesFile struct {
+	Composites []compositeRule `yaml:"composites"`
+}
+
+// composite rules match tables with a column from each group, like a bank account and routing number
+// column names are case-insensitive and underscores are ignored, like name rules
+type compositeRule struct {
+	Name        string     `yaml:"name"`
+	DisplayName string     `yaml:"display_name"`
+	Confidence  string     `yaml:"confidence"`
+	Columns     [][]string `yaml:"columns"`
+}
+
+var ruleName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var ruleConfidences = []string{"high", "medium", "low"}
+
+func loadRulesFile(filename string) ([]multiNameRule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var f rulesFile
+	err = yaml.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid rules file %s: %s", filename, err)
+	}
+
+	rules := []multiNameRule{}
+	for _, c := range f.Composites {
+		if !ruleName.MatchString(c.Name) {
+			return nil, fmt.Errorf("Invalid rules file %s: invalid rule name %q", filename, c.Name)
+		}
+		if len(c.Columns) < 2 {
+			return nil, fmt.Errorf("Invalid rules file %s: %s requires at least two column groups", filename, c.Name)
+		}
+
+		confidence := c.Confidence
+		if confidence == "" {
+			confidence = "medium"
+		} else if !stringInSlice(confidence, ruleConfidences) {
+			return nil, fmt.Errorf("Invalid rules file %s: invalid confidence %q for %s", filename, confidence, c.Name)
+		}
+
+		displayName := c.DisplayName
+		if displayName == "" {
+			displayName = strings.Replace(c.Name, "_", " ", -1)
+		}
+
+		columnNames := [][]string{}
+		for _, group := range c.Columns {
+			if len(group) == 0 {
+				return nil, fmt.Errorf("Invalid rules file %s: %s has an empty column group", filename, c.Name)
+			}
+			names := []string{}
+			for _, name := range group {
+				names = append(names, normalizeColumnName(name))
+			}
+			columnNames = append(columnNames, names)
+		}
+
+		rules = append(rules, multiNameRule{Name: c.Name, DisplayName: displayName, ColumnNames: columnNames, Confidence: confidence})
+	}
+	return rules, nil
+}
+
+func addRulesFile(matchConfig *MatchConfig, filename string) error {
+	rules, err := loadRulesFile(filename)
+	if err != nil {
+		return err
+	}
+
+	validNames := makeValidNames(matchConfig)
+	for _, rule := range rules {
+		if validNames[rule.Name] {
+			return fmt.Errorf("Invalid rules file %s: duplicate rule %s", filename, rule.Name)
+		}
+		validNames[rule.Name] = true
+	}
+	matchConfig.MultiNameRules = append(append([]multiNameRule{}, matchConfig.MultiNameRules...), rules...)
+	return nil
+}
+
+// like name rules, so first_name and FirstName are the same
+func normalizeColumnName(name string) string {
+	return strings.Replace(strings.ToLower(name), "_", "", -1)
+}
@@ -2,16 +2,32 @@ package internal
 
 import (
 	"bufio"
+	"fmt"
 	"net/url"
+	"os"
+	"regexp"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 type S3Adapter struct {
-	url string
+	url            string
+	modifiedFilter modifiedFilter
+	// roles to assume in order, like arn:aws:iam::{account}:role/pdscan
+	roles []string
+	// accounts to scan, with {account} in roles and the URL replaced by each
+	accounts []string
+	// sessions by role ARN, so each role chain is assumed once
+	sessions map[string]*session.Session
+	// account of each object, for the session and output
+	objectAccounts map[string]string
+	// sizes from listing objects, for the time budget
+	sizes map[string]int64
 }
 
 func (a *S3Adapter) ObjectName() string {
@@ -24,24 +40,69 @@ func (a *S3Adapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
 
 func (a *S3Adapter) Init(url string) error {
 	a.url = url
+	a.sessions = make(map[string]*session.Session)
+	a.objectAccounts = make(map[string]string)
+	a.sizes = make(map[string]int64)
 	return nil
 }
 
+func (a *S3Adapter) listedSize(file string) int64 {
+	return a.sizes[file]
+}
+
+func (a *S3Adapter) account(file string) string {
+	return a.objectAccounts[file]
+}
+
+func (a *S3Adapter) setModifiedFilter(filter modifiedFilter) {
+	a.modifiedFilter = filter
+}
+
 func (a S3Adapter) FetchFiles() ([]string, error) {
-	urlStr := a.url
-	var files []string
+	accounts := a.accounts
+	if len(accounts) == 1 && accounts[0] == "organization" {
+		var err error
+		accounts, err = organizationAccounts()
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	if strings.HasSuffix(urlStr, "/") {
-		u, err := url.Parse(urlStr)
+	if len(accounts) == 0 {
+		return a.fetchAccountFiles("")
+	}
+
+	// one inaccessible account should not stop the scan
+	var files []string
+	for _, account := range accounts {
+		accountFiles, err := a.fetchAccountFiles(account)
 		if err != nil {
-			return files, err
+			fmt.Fprintf(os.Stderr, "Skipped account %s (%s)\n", account, err)
+			continue
 		}
-		bucket := u.Host
-		key := u.Path[1:]
+		fmt.Fprintf(os.Stderr, "Found %s in account %s\n", pluralize(len(accountFiles), a.ObjectName()), account)
+		files = append(files, accountFiles...)
+	}
+	return files, nil
+}
+
+func (a S3Adapter) fetchAccountFiles(account string) ([]string, error) {
+	urlStr := strings.Replace(a.url, "{account}", account, -1)
+	var files []string
+
+	sess, err := a.accountSession(account)
+	if err != nil {
+		return files, err
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return files, err
+	}
+	bucket := u.Host
 
-		sess := session.Must(session.NewSessionWithOptions(session.Options{
-			SharedConfigState: session.SharedConfigEnable,
-		}))
+	if strings.HasSuffix(urlStr, "/") {
+		key := u.Path[1:]
 
 		svc := s3.New(sess)
 
@@ -50,22 +111,130 @@ func (a S3Adapter) FetchFiles() ([]string, error) {
 			Prefix: aws.String(key),
 		}
 
-		resp, _ := svc.ListObjects(params)
+		resp, err := svc.ListObjects(params)
+		if err != nil {
+			return files, err
+		}
+		var objects []modifiedObject
 		for _, key := range resp.Contents {
-			files = append(files, "s3://"+bucket+"/"+*key.Key)
+			objects = append(objects, modifiedObject{"s3://" + bucket + "/" + *key.Key, aws.TimeValue(key.LastModified)})
+			a.sizes["s3://"+bucket+"/"+*key.Key] = aws.Int64Value(key.Size)
 		}
+		files = a.modifiedFilter.apply(objects)
 	} else {
 		files = append(files, urlStr)
 	}
 
+	for _, file := range files {
+		a.objectAccounts[file] = account
+	}
+
 	return files, nil
 }
 
-func (a S3Adapter) FindFileMatches(filename string, matchFinder *MatchFinder) error {
-	sess := session.Must(session.NewSessionWithOptions(session.Options{
+// roles are chained, so each role is assumed with the credentials of the one before
+// buckets in the same account share the session
+func (a S3Adapter) accountSession(account string) (*session.Session, error) {
+	key := a.roleArn(account)
+	if sess, ok := a.sessions[key]; ok {
+		return sess, nil
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range a.roles {
+		arn := strings.Replace(role, "{account}", account, -1)
+		sess = sess.Copy(&aws.Config{Credentials: stscreds.NewCredentials(sess, arn)})
+	}
+	a.sessions[key] = sess
+	return sess, nil
+}
+
+// last role in the chain, or empty without roles
+func (a S3Adapter) roleArn(account string) string {
+	if len(a.roles) == 0 {
+		return ""
+	}
+	return strings.Replace(a.roles[len(a.roles)-1], "{account}", account, -1)
+}
+
+func (a S3Adapter) session(file string) *session.Session {
+	if sess, ok := a.sessions[a.roleArn(a.objectAccounts[file])]; ok {
+		return sess
+	}
+	return session.Must(session.NewSessionWithOptions(session.Options{
 		SharedConfigState: session.SharedConfigEnable,
 	}))
+}
+
+// active accounts in the AWS Organization, from the management or a delegated administrator account
+func organizationAccounts() ([]string, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []string
+	svc := organizations.New(sess)
+	err = svc.ListAccountsPages(&organizations.ListAccountsInput{}, func(page *organizations.ListAccountsOutput, lastPage bool) bool {
+		for _, account := range page.Accounts {
+			if aws.StringValue(account.Status) == organizations.AccountStatusActive {
+				accounts = append(accounts, aws.StringValue(account.Id))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error listing organization accounts: %s", err)
+	}
+	return accounts, nil
+}
+
+var accountIdRegex = regexp.MustCompile(`^\d{12}$`)
+
+// account IDs, like 111111111111,222222222222, or organization for all accounts
+func parseAccounts(value string) ([]string, error) {
+	if value == "organization" {
+		return []string{value}, nil
+	}
+
+	accounts := []string{}
+	for _, account := range strings.Split(value, ",") {
+		account = strings.TrimSpace(account)
+		if !accountIdRegex.MatchString(account) {
+			return nil, fmt.Errorf("Invalid account: %s", account)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (a S3Adapter) FileSize(filename string) (int64, error) {
+	u, err := url.Parse(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	svc := s3.New(a.session(filename))
+	resp, err := svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(u.Host),
+		Key:    aws.String(u.Path),
+	})
+	if err != nil {
+		return 0, err
+	}
 
+	return aws.Int64Value(resp.ContentLength), nil
+}
+
+func (a S3Adapter) FindFileMatches(filename string, matchFinder *MatchFinder) error {
 	u, err := url.Parse(filename)
 	if err != nil {
 		return err
@@ -73,10 +242,9 @@ func (a S3Adapter) FindFileMatches(filename string, matchFinder *MatchFinder) er
 	bucket := u.Host
 	key := u.Path
 
-	// TODO stream
 	// TODO get file type before full download
-	svc := s3.New(sess)
-	resp, err := svc.GetObject(&s3.GetObjectInput{
+	svc := s3.New(a.session(filename))
+	resp, err := svc.GetObjectWithContext(matchFinder.ctx, &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
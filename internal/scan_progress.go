@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// older progress is discarded, since the data has likely changed
+const scanProgressMaxAge = 7 * 24 * time.Hour
+
+// progress is saved to the state file after each table, so a scan that is interrupted,
+// like by a crash or a restart, leaves its results so far and the next scan resumes from there
+type scanProgress struct {
+	StartedAt time.Time `json:"started_at"`
+	// URL without the password
+	Source string `json:"source"`
+	// options that change matches, so a scan with different options starts over
+	Options string                  `json:"options"`
+	Tables  map[string]scannedTable `json:"tables"`
+	Matches []progressMatch         `json:"matches"`
+}
+
+type scannedTable struct {
+	Rows int `json:"rows"`
+	// committed when the scan finishes
+	Watermark *watermark `json:"watermark,omitempty"`
+}
+
+// matched data is never stored
+type progressMatch struct {
+	Source      string         `json:"source"`
+	Identifier  string         `json:"identifier"`
+	Rule        string         `json:"rule"`
+	DisplayName string         `json:"display_name"`
+	MatchType   string         `json:"match_type"`
+	Confidence  string         `json:"confidence"`
+	Count       int            `json:"count"`
+	Brands      map[string]int `json:"brands,omitempty"`
+	Truncated   bool           `json:"truncated,omitempty"`
+}
+
+// like a hash of the options, so option values are not stored
+func scanOptionsFingerprint(values ...interface{}) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%#v", values)))
+	return hex.EncodeToString(hash[:8])
+}
+
+// returns the number of tables already scanned when resuming an interrupted scan
+// progress from a different source or options, or older than scanProgressMaxAge, is discarded
+func (s *watermarkState) startScan(source string, options string, now time.Time) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	discarded := false
+	if s.Scan != nil && (s.Scan.Source != source || s.Scan.Options != options || now.Sub(s.Scan.StartedAt) > scanProgressMaxAge) {
+		s.Scan = nil
+		discarded = true
+	}
+
+	if s.Scan == nil {
+		s.Scan = &scanProgress{StartedAt: now, Source: source, Options: options, Tables: make(map[string]scannedTable), Matches: []progressMatch{}}
+		return 0, discarded
+	}
+	if s.Scan.Tables == nil {
+		s.Scan.Tables = make(map[string]scannedTable)
+	}
+	return len(s.Scan.Tables), false
+}
+
+// returns the matches and rows sampled for a table from an interrupted scan
+// matched data is not stored, so the matches have no values, even with --show-data
+func (s *watermarkState) resumeTable(name string) ([]ruleMatch, int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	scanned, ok := s.Scan.Tables[name]
+	if !ok {
+		return nil, 0, false
+	}
+
+	matchList := []ruleMatch{}
+	for _, match := range s.Scan.Matches {
+		if match.Source == name {
+			matchList = append(matchList, ruleMatch{RuleName: match.Rule, DisplayName: match.DisplayName, Confidence: match.Confidence, Source: match.Source, Identifier: match.Identifier, MatchType: match.MatchType, LineCount: match.Count, Brands: match.Brands, Truncated: match.Truncated})
+		}
+	}
+	return matchList, scanned.Rows, true
+}
+
+// records the matches for a table and saves the state file
+func (s *watermarkState) completeTable(name string, rows int, matchList []ruleMatch) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	scanned := scannedTable{Rows: rows}
+	if w, ok := s.pending[name]; ok {
+		scanned.Watermark = &w
+	}
+	s.Scan.Tables[name] = scanned
+
+	for _, match := range matchList {
+		s.Scan.Matches = append(s.Scan.Matches, progressMatch{Source: name, Identifier: match.Identifier, Rule: match.RuleName, DisplayName: match.DisplayName, MatchType: match.MatchType, Confidence: match.Confidence, Count: match.LineCount, Brands: match.Brands, Truncated: match.Truncated})
+	}
+
+	return s.save()
+}
+
+// commits watermarks for scanned tables and clears the progress
+// tables that were skipped, like from timeouts, are sampled from their previous watermark next time
+func (s *watermarkState) finishScan() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Scan != nil {
+		for name, scanned := range s.Scan.Tables {
+			if scanned.Watermark != nil {
+				s.Tables[name] = *scanned.Watermark
+			}
+		}
+		s.Scan = nil
+	}
+
+	return s.save()
+}
@@ -0,0 +1,219 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scope limits which tables are scanned
+// patterns are matched against table display names and support wildcards
+type scope struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// metadataAdapter is implemented by adapters that can list columns without reading rows
+type metadataAdapter interface {
+	FetchColumns(ctx context.Context, table table) ([]string, []string, error)
+}
+
+func loadScope(filename string) (*scope, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var s scope
+	err = yaml.Unmarshal(data, &s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid scope file %s: %s", filename, err)
+	}
+	return &s, nil
+}
+
+func (s *scope) includes(table table) bool {
+	name := table.displayName()
+	if len(s.Include) > 0 && !matchesAny(name, s.Include) {
+		return false
+	}
+	return !matchesAny(name, s.Exclude)
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, name)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+type scopeEntry struct {
+	Table   string
+	Columns []string
+}
+
+func GenerateScope(urlStr string, limit int, output io.Writer) error {
+	adapter, ok := newAdapter(urlStr, "").(DataStoreAdapter)
+	if !ok {
+		return fmt.Errorf("scope generate is only supported for data stores")
+	}
+
+	err := adapter.Init(urlStr)
+	if err != nil {
+		return err
+	}
+
+	tables, err := adapter.FetchTables()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Found %s to classify...\n", pluralize(len(tables), adapter.TableName()))
+
+	matchConfig := NewMatchConfig()
+
+	likely := []scopeEntry{}
+	possible := []scopeEntry{}
+	unlikely := []scopeEntry{}
+
+	for _, table := range tables {
+		var columnNames []string
+		var columnTypes []string
+
+		metadata, ok := adapter.(metadataAdapter)
+		if ok {
+			columnNames, columnTypes, err = metadata.FetchColumns(context.Background(), table)
+		} else {
+			// infer schema from a sample for schemaless data stores
+			var data *tableData
+			data, err = adapter.FetchTableData(context.Background(), table, limit)
+			if err == nil {
+				columnNames = data.ColumnNames
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := scopeEntry{Table: table.displayName(), Columns: sensitiveColumnNames(columnNames, &matchConfig)}
+		if len(entry.Columns) > 0 {
+			likely = append(likely, entry)
+		} else if anyTextColumns(columnNames, columnTypes) {
+			possible = append(possible, entry)
+		} else {
+			unlikely = append(unlikely, entry)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d likely, %d possibly, and %d unlikely to have sensitive data\n", len(likely), len(possible), len(unlikely))
+
+	include := &yaml.Node{Kind: yaml.SequenceNode}
+	appendScopeEntries(include, likely, "likely sensitive (column names)")
+	appendScopeEntries(include, possible, "possibly sensitive (text columns)")
+
+	exclude := &yaml.Node{Kind: yaml.SequenceNode}
+	appendScopeEntries(exclude, unlikely, "unlikely sensitive (no text columns)")
+
+	doc := &yaml.Node{
+		Kind:        yaml.MappingNode,
+		HeadComment: "generated by pdscan scope generate\nreview before use with --scope",
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "include"},
+			include,
+			{Kind: yaml.ScalarNode, Value: "exclude"},
+			exclude,
+		},
+	}
+
+	encoder := yaml.NewEncoder(output)
+	encoder.SetIndent(2)
+	err = encoder.Encode(doc)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func appendScopeEntries(node *yaml.Node, entries []scopeEntry, comment string) {
+	for i, entry := range entries {
+		item := &yaml.Node{Kind: yaml.ScalarNode, Value: entry.Table}
+		if i == 0 {
+			item.HeadComment = comment
+		}
+		if len(entry.Columns) > 0 {
+			item.LineComment = strings.Join(entry.Columns, ", ")
+		}
+		node.Content = append(node.Content, item)
+	}
+}
+
+// column names that suggest sensitive data
+// regex rule names are used as hints since their values are not sampled
+func sensitiveColumnNames(columnNames []string, matchConfig *MatchConfig) []string {
+	hints := []string{}
+	for _, rule := range matchConfig.RegexRules {
+		hints = append(hints, strings.Replace(rule.Name, "_", "", -1))
+	}
+
+	columns := []string{}
+	for _, col := range columnNames {
+		name := strings.Replace(strings.ToLower(col), "_", "", -1)
+
+		// check last part for nested data
+		parts := strings.Split(name, ".")
+		name = parts[len(parts)-1]
+
+		if matchNameRule(name, matchConfig.NameRules).Name != "" || stringInSlice(name, hints) {
+			columns = append(columns, col)
+		}
+	}
+
+	for _, rule := range matchConfig.MultiNameRules {
+		var latCol string
+		var lonCol string
+		for _, col := range columnNames {
+			if stringInSlice(col, rule.ColumnNames[0]) {
+				latCol = col
+			} else if stringInSlice(col, rule.ColumnNames[1]) {
+				lonCol = col
+			}
+		}
+		if latCol != "" && lonCol != "" {
+			columns = append(columns, latCol+"+"+lonCol)
+		}
+	}
+
+	return columns
+}
+
+var textTypes = []string{"CHAR", "NCHAR", "VARCHAR", "NVARCHAR", "BPCHAR", "CHARACTER", "TEXT", "NTEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "STRING", "CLOB", "NCLOB", "JSON", "JSONB", "XML"}
+var nonTextTypes = append([]string{"FLOAT", "REAL", "DOUBLE", "MONEY", "SMALLMONEY", "BOOL", "BOOLEAN", "BIT", "DATE", "DATETIME", "SMALLDATETIME", "DATETIMEOFFSET", "TIME", "TIMETZ", "TIMESTAMP", "TIMESTAMPTZ", "INTERVAL", "YEAR"}, integerTypes...)
+
+// unknown types are treated as text
+func anyTextColumns(columnNames []string, columnTypes []string) bool {
+	if len(columnTypes) == 0 {
+		return len(columnNames) > 0
+	}
+
+	for _, ty := range columnTypes {
+		if isTextType(ty) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTextType(ty string) bool {
+	if hasTypeName(ty, textTypes) {
+		return true
+	}
+	return !hasTypeName(ty, nonTextTypes)
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"errors"
+	"net/url"
+	"os"
+)
+
+// SendgridAdapter scans subjects of recent emails with the SendGrid Email Activity API
+// SendGrid doesn't keep email bodies, so they can't be scanned
+type SendgridAdapter struct {
+	RestAdapter
+}
+
+func (a *SendgridAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *SendgridAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	key, ok := u.User.Password()
+	if !ok {
+		key = os.Getenv("SENDGRID_API_KEY")
+	}
+	if key == "" {
+		return errors.New("no API key specified")
+	}
+
+	return a.init(newSendgridConfig("https://api.sendgrid.com", key))
+}
+
+// the API returns up to 1000 messages and has no pagination
+func newSendgridConfig(endpoint string, key string) *restConfig {
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "bearer"
+	config.Auth.Token = key
+	config.Resources = []restResource{
+		{Name: "messages", Path: "/v3/messages", Items: "messages", Fields: []string{"subject"}, Pagination: restPagination{SizeParam: "limit", Size: 1000}},
+	}
+	return config
+}
@@ -0,0 +1,488 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// finished scans are kept this long for polling
+const scanResultTTL = time.Hour
+
+// max size of uploaded content
+const maxUploadSize = 100 * 1024 * 1024
+
+// scan requests, like {"url": "postgres://...", "only": "email,phone"}
+type scanRequest struct {
+	Url        string `json:"url"`
+	Only       string `json:"only"`
+	Except     string `json:"except"`
+	SampleSize int    `json:"sample_size"`
+	ShowAll    bool   `json:"show_all"`
+}
+
+// results never include matched data, only where it was found, like notifications
+// unless the server keeps redacted samples for the dashboard
+type scanResult struct {
+	Id           string          `json:"id"`
+	Status       string          `json:"status"`
+	Source       string          `json:"source"`
+	Error        string          `json:"error,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty"`
+	MatchesCount int             `json:"matches_count"`
+	Matches      []scanMatch     `json:"matches"`
+	Skipped      []skippedObject `json:"skipped"`
+}
+
+type scanMatch struct {
+	notificationMatch
+	// redacted, with --dashboard-samples
+	Samples []string `json:"samples,omitempty"`
+}
+
+type scanJob struct {
+	result      scanResult
+	adapter     Adapter
+	urlStr      string
+	limit       int
+	showAll     bool
+	matchConfig MatchConfig
+	// uploaded content, removed after the scan
+	uploadPath string
+}
+
+// scanServer runs scans on demand, so teams don't need the CLI and credentials
+// scans are queued and run by a fixed number of workers
+type scanServer struct {
+	jobs  map[string]*scanJob
+	queue chan *scanJob
+	token string
+	mutex sync.Mutex
+	// summaries of finished scans, kept longer than results for trends
+	runs      []runSummary
+	dashboard bool
+	// redacts samples kept for the dashboard, or nil to keep none
+	redactor *redactor
+}
+
+func newScanServer(concurrency int, queueSize int, token string) *scanServer {
+	s := &scanServer{
+		jobs:  make(map[string]*scanJob),
+		queue: make(chan *scanJob, queueSize),
+		token: token,
+	}
+	for i := 0; i < concurrency; i++ {
+		go s.work()
+	}
+	return s
+}
+
+func Serve(listen string, concurrency int, queueSize int, dashboard bool, dashboardSamples string) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be positive")
+	}
+	if queueSize < 1 {
+		return fmt.Errorf("queue-size must be positive")
+	}
+
+	var samplesRedactor *redactor
+	if dashboardSamples != "" {
+		if !dashboard {
+			return fmt.Errorf("--dashboard-samples requires --dashboard")
+		}
+		var err error
+		samplesRedactor, err = newRedactor(dashboardSamples)
+		if err != nil {
+			return err
+		}
+	}
+
+	token := os.Getenv("PDSCAN_API_TOKEN")
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "Warning: PDSCAN_API_TOKEN is not set, so requests are not authenticated")
+	}
+
+	ctx, stop := shutdownContext()
+	defer stop()
+
+	// stop accepting requests and let responses finish when stopped, like by systemd
+	scanServer := newScanServer(concurrency, queueSize, token)
+	scanServer.dashboard = dashboard
+	scanServer.redactor = samplesRedactor
+	server := &http.Server{Addr: listen, Handler: scanServer}
+	stopped := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+		close(stopped)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", listen)
+	err := server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		<-stopped
+		fmt.Fprintln(os.Stderr, "Stopped")
+		return nil
+	}
+	return err
+}
+
+func (s *scanServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the page has no data, and asks for the token to request it
+	if s.dashboard && r.URL.Path == "/" && r.Method == http.MethodGet {
+		serveDashboard(w)
+		return
+	}
+
+	if s.token != "" {
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+s.token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+	}
+
+	switch {
+	case r.URL.Path == "/scans" && r.Method == http.MethodPost:
+		s.createScan(w, r)
+	case r.URL.Path == "/scans/upload" && r.Method == http.MethodPost:
+		s.uploadScan(w, r)
+	case strings.HasPrefix(r.URL.Path, "/scans/") && r.Method == http.MethodGet:
+		s.showScan(w, strings.TrimPrefix(r.URL.Path, "/scans/"))
+	case r.URL.Path == "/findings" && r.Method == http.MethodGet:
+		s.listFindings(w, r)
+	case r.URL.Path == "/runs" && r.Method == http.MethodGet:
+		s.listRuns(w)
+	case r.URL.Path == "/sources" && r.Method == http.MethodGet:
+		s.listSources(w)
+	default:
+		writeJSONError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *scanServer) createScan(w http.ResponseWriter, r *http.Request) {
+	var req scanRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if req.Url == "" {
+		writeJSONError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+	err = checkServerUrl(req.Url)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := newScanJob(req, newAdapter(req.Url, ""), req.Url)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.enqueue(w, job)
+}
+
+// network data stores, since other URLs, like sqlite:, duckdb:, and rest:, read the server's files
+var serverSchemes = []string{
+	"postgres", "postgresql", "greenplum", "mysql", "mariadb", "sqlserver", "azuresql", "oracle", "exasol",
+	"mongodb", "redis", "kafka", "elasticsearch+http", "elasticsearch+https", "opensearch+http", "opensearch+https", "s3",
+	"cosmosdb", "okta", "auth0", "hubspot", "mailchimp", "twilio", "sendgrid", "mixpanel", "amplitude", "pagerduty", "opsgenie",
+}
+
+// the host comes from the URL, so credentials from the server's environment could be sent anywhere,
+// like PGPASSWORD and PGPASSFILE for Postgres or the default Azure credential chain
+var serverCredentialSchemes = []string{
+	"postgres", "postgresql", "greenplum", "mysql", "mariadb", "sqlserver", "azuresql", "oracle", "exasol",
+	"cosmosdb", "okta", "auth0",
+}
+
+func checkServerUrl(urlStr string) error {
+	// the server's files are not exposed, so content must be uploaded
+	if urlStr == "-" || strings.HasPrefix(urlStr, "file:") {
+		return errors.New("File URLs are not supported, use /scans/upload")
+	}
+
+	scheme, _, found := strings.Cut(urlStr, "://")
+	if !found || !stringInSlice(scheme, serverSchemes) {
+		return fmt.Errorf("Unsupported URL for the server: %s\nSupported schemes are %s", redactUrl(urlStr), strings.Join(serverSchemes, ", "))
+	}
+
+	if stringInSlice(scheme, serverCredentialSchemes) {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+		if _, ok := u.User.Password(); !ok {
+			return fmt.Errorf("%s URLs must include credentials", scheme)
+		}
+	}
+	return nil
+}
+
+// the request body is the content, like curl --data-binary @file.txt
+// the name is used in identifiers, like /scans/upload?name=file.txt
+func (s *scanServer) uploadScan(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := scanRequest{Only: query.Get("only"), Except: query.Get("except"), ShowAll: query.Get("show_all") == "true"}
+
+	name := path.Base(query.Get("name"))
+	if name == "." || name == "/" {
+		name = "upload"
+	}
+
+	file, err := os.CreateTemp("", "pdscan-upload")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	_, err = io.Copy(file, http.MaxBytesReader(w, r.Body, maxUploadSize))
+	file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		writeJSONError(w, http.StatusBadRequest, "Error reading upload: "+err.Error())
+		return
+	}
+
+	job, err := newScanJob(req, &uploadAdapter{name: name, path: file.Name()}, name)
+	if err != nil {
+		os.Remove(file.Name())
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	job.uploadPath = file.Name()
+	s.enqueue(w, job)
+}
+
+func (s *scanServer) showScan(w http.ResponseWriter, id string) {
+	s.mutex.Lock()
+	job, ok := s.jobs[id]
+	var result scanResult
+	if ok {
+		result = job.result
+	}
+	s.mutex.Unlock()
+
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Scan not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func newScanJob(req scanRequest, adapter Adapter, source string) (*scanJob, error) {
+	limit := req.SampleSize
+	if limit == 0 {
+		limit = 10000
+	} else if limit < 0 {
+		return nil, fmt.Errorf("sample_size must be positive")
+	}
+
+	matchConfig := NewMatchConfig()
+	if req.Except != "" {
+		err := updateRules(&matchConfig, req.Except, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Only != "" {
+		err := updateRules(&matchConfig, req.Only, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scanJob{
+		result: scanResult{
+			Id:        hex.EncodeToString(id),
+			Status:    "queued",
+			Source:    redactUrl(source),
+			CreatedAt: time.Now().UTC(),
+			Matches:   []scanMatch{},
+			Skipped:   []skippedObject{},
+		},
+		adapter:     adapter,
+		urlStr:      req.Url,
+		limit:       limit,
+		showAll:     req.ShowAll,
+		matchConfig: matchConfig,
+	}, nil
+}
+
+func (s *scanServer) enqueue(w http.ResponseWriter, job *scanJob) {
+	s.mutex.Lock()
+	s.prune()
+	select {
+	case s.queue <- job:
+		s.jobs[job.result.Id] = job
+		result := job.result
+		s.mutex.Unlock()
+		writeJSON(w, http.StatusAccepted, result)
+	default:
+		s.mutex.Unlock()
+		if job.uploadPath != "" {
+			os.Remove(job.uploadPath)
+		}
+		writeJSONError(w, http.StatusServiceUnavailable, "Queue is full")
+	}
+}
+
+// must be called with the mutex held
+func (s *scanServer) prune() {
+	for id, job := range s.jobs {
+		if job.result.FinishedAt != nil && time.Since(*job.result.FinishedAt) > scanResultTTL {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *scanServer) work() {
+	for job := range s.queue {
+		s.mutex.Lock()
+		job.result.Status = "running"
+		s.mutex.Unlock()
+
+		s.run(job)
+	}
+}
+
+func (s *scanServer) run(job *scanJob) {
+	scanCoverage := &coverage{}
+	matchList, err := runScanJob(job, scanCoverage)
+	if job.uploadPath != "" {
+		os.Remove(job.uploadPath)
+	}
+
+	s.mutex.Lock()
+	finishedAt := time.Now().UTC()
+	job.result.FinishedAt = &finishedAt
+	if err != nil {
+		job.result.Status = "failed"
+		job.result.Error = err.Error()
+	} else {
+		job.result.Status = "completed"
+		job.result.Matches = s.scanMatches(job, matchList)
+		job.result.MatchesCount = len(job.result.Matches)
+		job.result.Skipped = append(job.result.Skipped, scanCoverage.Skipped()...)
+	}
+	s.recordRun(job.result)
+	s.mutex.Unlock()
+}
+
+func (s *scanServer) scanMatches(job *scanJob, matchList []ruleMatch) []scanMatch {
+	samples := make(map[string][]string)
+	if s.redactor != nil {
+		for _, match := range matchList {
+			samples[match.Identifier+"\x00"+match.RuleName] = matchValues(match, dashboardSampleLimit, s.redactor)
+		}
+	}
+
+	matches := []scanMatch{}
+	for _, match := range newNotification(job.result.Source, nil, matchList, job.showAll).Matches {
+		matches = append(matches, scanMatch{notificationMatch: match, Samples: samples[match.Identifier+"\x00"+match.Name]})
+	}
+	return matches
+}
+
+// a panic in an adapter fails the job instead of stopping the server
+func runScanJob(job *scanJob, scanCoverage *coverage) (matchList []ruleMatch, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			matchList = nil
+			err = fmt.Errorf("scan failed unexpectedly: %v", r)
+		}
+	}()
+
+	return job.adapter.Scan(ScanOpts{
+		UrlStr:      job.urlStr,
+		ShowAll:     job.showAll,
+		Limit:       job.limit,
+		Formatter:   discardFormatter{},
+		MatchConfig: &job.matchConfig,
+		Coverage:    scanCoverage,
+	})
+}
+
+// uploadAdapter scans content uploaded to the server
+type uploadAdapter struct {
+	name string
+	path string
+}
+
+func (a *uploadAdapter) ObjectName() string {
+	return "upload"
+}
+
+func (a *uploadAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanFiles(a, scanOpts)
+}
+
+func (a *uploadAdapter) Init(url string) error {
+	return nil
+}
+
+func (a uploadAdapter) FetchFiles() ([]string, error) {
+	return []string{a.name}, nil
+}
+
+func (a uploadAdapter) FileSize(filename string) (int64, error) {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (a uploadAdapter) FindFileMatches(filename string, matchFinder *MatchFinder) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return processFile(f, matchFinder)
+}
+
+// results are returned by the API instead of printed
+type discardFormatter struct{}
+
+func (f discardFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
+	return nil
+}
+
+func (f discardFormatter) Finish(writer io.Writer) error {
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
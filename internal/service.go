@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// serviceConfig runs pdscan serve as a daemon, or a scan on a schedule
+type serviceConfig struct {
+	Name       string
+	Executable string
+	Args       []string
+	// systemd calendar event, like daily, for scans
+	Schedule string
+	// URL with a password, which is kept out of the unit file
+	Url string
+}
+
+var serviceNameRegex = regexp.MustCompile(`\A[A-Za-z0-9_-]+\z`)
+
+// systemd replaces it with the URL from the environment file, as a single argument
+const serviceUrlArg = "${PDSCAN_URL}"
+
+// ServiceInstall registers pdscan as a systemd unit or Windows service
+// with dryRun, the service is printed instead of installed
+func ServiceInstall(name string, schedule string, args []string, dryRun bool, output io.Writer) error {
+	if !serviceNameRegex.MatchString(name) {
+		return fmt.Errorf("Invalid service name: %s", name)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("Specify the command to run, like: pdscan service install -- serve")
+	}
+
+	// serve runs until stopped, and scans exit when finished
+	if args[0] == "serve" && schedule != "" {
+		return fmt.Errorf("--schedule is only supported for scans")
+	} else if args[0] != "serve" && schedule == "" {
+		return fmt.Errorf("--schedule is required for scans")
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return err
+	}
+
+	config := serviceConfig{Name: name, Executable: executable, Args: append([]string{}, args...), Schedule: schedule}
+	for i, arg := range config.Args {
+		u, err := url.Parse(arg)
+		if err != nil || u.User == nil {
+			continue
+		}
+		if _, ok := u.User.Password(); !ok {
+			continue
+		}
+		if config.Url != "" {
+			return fmt.Errorf("Only one URL with a password is supported")
+		}
+		config.Url = arg
+		config.Args[i] = serviceUrlArg
+	}
+
+	return installService(config, dryRun, output)
+}
+
+// only readable by root, unlike unit files
+func (c serviceConfig) urlEnvFile() string {
+	return fmt.Sprintf("/etc/pdscan/%s-url.env", c.Name)
+}
+
+func (c serviceConfig) urlEnv(urlStr string) string {
+	return "PDSCAN_URL=\"" + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(urlStr) + "\"\n"
+}
+
+// credentials, like PDSCAN_API_TOKEN, can be set in an environment file
+// output goes to the journal, so logs are available with journalctl -u name
+func (c serviceConfig) systemdUnits() map[string]string {
+	command := []string{systemdQuote(c.Executable)}
+	for _, arg := range c.Args {
+		if arg == serviceUrlArg {
+			command = append(command, arg)
+		} else {
+			command = append(command, systemdQuote(arg))
+		}
+	}
+
+	var service strings.Builder
+	fmt.Fprintf(&service, "[Unit]\nDescription=pdscan (%s)\nWants=network-online.target\nAfter=network-online.target\n\n", c.Name)
+	service.WriteString("[Service]\n")
+	if c.Schedule == "" {
+		service.WriteString("Type=simple\nRestart=on-failure\nRestartSec=5\n")
+	} else {
+		service.WriteString("Type=oneshot\n")
+	}
+	fmt.Fprintf(&service, "ExecStart=%s\n", strings.Join(command, " "))
+	fmt.Fprintf(&service, "EnvironmentFile=-/etc/pdscan/%s.env\n", c.Name)
+	if c.Url != "" {
+		fmt.Fprintf(&service, "EnvironmentFile=%s\n", c.urlEnvFile())
+	}
+	fmt.Fprintf(&service, "KillSignal=SIGTERM\nTimeoutStopSec=30\nStandardOutput=journal\nStandardError=journal\nSyslogIdentifier=%s\n", c.Name)
+	if c.Schedule == "" {
+		service.WriteString("\n[Install]\nWantedBy=multi-user.target\n")
+	}
+
+	units := map[string]string{c.Name + ".service": service.String()}
+	if c.Schedule != "" {
+		units[c.Name+".timer"] = fmt.Sprintf("[Unit]\nDescription=pdscan schedule (%s)\n\n[Timer]\nOnCalendar=%s\nPersistent=true\n\n[Install]\nWantedBy=timers.target\n", c.Name, c.Schedule)
+	}
+	return units
+}
+
+// https://www.freedesktop.org/software/systemd/man/systemd.service.html#Command%20lines
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
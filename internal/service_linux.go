@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+const systemdUnitDir = "/etc/systemd/system"
+
+func installService(config serviceConfig, dryRun bool, output io.Writer) error {
+	units := config.systemdUnits()
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if dryRun {
+		for _, name := range names {
+			fmt.Fprintf(output, "# %s\n%s\n", filepath.Join(systemdUnitDir, name), units[name])
+		}
+		if config.Url != "" {
+			fmt.Fprintf(output, "# %s (mode 0600)\n%s", config.urlEnvFile(), config.urlEnv(redactUrl(config.Url)))
+		}
+		return nil
+	}
+
+	if config.Url != "" {
+		err := writeUrlEnvFile(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		err := os.WriteFile(filepath.Join(systemdUnitDir, name), []byte(units[name]), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	// timers start the service on schedule
+	unit := config.Name + ".service"
+	if config.Schedule != "" {
+		unit = config.Name + ".timer"
+	}
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", "--now", unit}} {
+		out, err := exec.Command("systemctl", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("systemctl %s failed: %s", args[0], out)
+		}
+	}
+
+	fmt.Fprintf(output, "Installed %s\n", unit)
+	fmt.Fprintf(output, "View logs with: journalctl -u %s.service\n", config.Name)
+	return nil
+}
+
+// the URL has a password, so it's not written to the world-readable unit file
+func writeUrlEnvFile(config serviceConfig) error {
+	filename := config.urlEnvFile()
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	// an existing file can have other permissions
+	err = f.Chmod(0600)
+	if err == nil {
+		_, err = f.WriteString(config.urlEnv(config.Url))
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
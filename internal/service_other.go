@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package internal
+
+import (
+	"fmt"
+	"io"
+)
+
+func installService(config serviceConfig, dryRun bool, output io.Writer) error {
+	return fmt.Errorf("service install is only supported on Linux with systemd and Windows")
+}
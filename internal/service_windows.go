@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// services have no console, so output goes to a log file
+func serviceLogFile(name string) string {
+	return filepath.Join(os.Getenv("ProgramData"), "pdscan", name+".log")
+}
+
+func installService(config serviceConfig, dryRun bool, output io.Writer) error {
+	if config.Schedule != "" {
+		return fmt.Errorf("--schedule is not supported for Windows services, use Task Scheduler for scans")
+	}
+	if config.Url != "" {
+		return fmt.Errorf("URLs with a password are not supported for Windows services")
+	}
+
+	if dryRun {
+		fmt.Fprintf(output, "Service: %s\nCommand: %s %s\nLogs: %s\n", config.Name, config.Executable, strings.Join(config.Args, " "), serviceLogFile(config.Name))
+		return nil
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.CreateService(config.Name, config.Executable, mgr.Config{
+		DisplayName: "pdscan (" + config.Name + ")",
+		Description: "Scan data stores for unencrypted personal data",
+		StartType:   mgr.StartAutomatic,
+	}, config.Args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// the service name is not passed to the process, so set it in the service environment for logs
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+config.Name, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	err = key.SetStringsValue("Environment", []string{"PDSCAN_SERVICE_NAME=" + config.Name})
+	if err != nil {
+		return err
+	}
+
+	err = s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 5 * time.Second}}, 24*60*60)
+	if err != nil {
+		return err
+	}
+
+	err = s.Start()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(output, "Installed %s\n", config.Name)
+	fmt.Fprintf(output, "Logs are written to %s\n", serviceLogFile(config.Name))
+	return nil
+}
+
+// when run by the service manager, stop requests cancel the context
+// and the service reports stopped once the returned function is called
+func shutdownContext() (context.Context, func()) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return ctx, cancel
+	}
+
+	name := os.Getenv("PDSCAN_SERVICE_NAME")
+	if name == "" {
+		name = "pdscan"
+	}
+	logFile := serviceLogFile(name)
+	err = os.MkdirAll(filepath.Dir(logFile), 0755)
+	if err == nil {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err == nil {
+			os.Stdout = f
+			os.Stderr = f
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		svc.Run(name, serviceHandler{cancel: cancel, done: done})
+		cancel()
+	}()
+
+	return ctx, func() {
+		cancel()
+		close(done)
+	}
+}
+
+type serviceHandler struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (h serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	accepts := svc.AcceptStop | svc.AcceptShutdown
+	status <- svc.Status{State: svc.Running, Accepts: accepts}
+
+	for {
+		select {
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				h.cancel()
+				select {
+				case <-h.done:
+				case <-time.After(30 * time.Second):
+				}
+				return false, 0
+			}
+		case <-h.done:
+			return false, 0
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package internal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// systemd and container runtimes stop processes with SIGTERM
+func shutdownContext() (context.Context, func()) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return ctx, cancel
+}
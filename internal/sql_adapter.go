@@ -1,22 +1,54 @@
 package internal
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/xo/dburl"
 
-	_ "github.com/denisenkom/go-mssqldb"
-	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/denisenkom/go-mssqldb/azuread"
+	_ "github.com/exasol/exasol-driver-go"
+	_ "github.com/marcboeker/go-duckdb"
 	_ "github.com/mattn/go-sqlite3"
+	_ "github.com/sijms/go-ora/v2"
 )
 
+func init() {
+	// Azure SQL with Azure Active Directory authentication
+	dburl.Register(dburl.Scheme{Driver: "azuresql", Generator: genAzureSql, Aliases: []string{"azure"}})
+	sqlx.BindDriver("azuresql", sqlx.AT)
+
+	dburl.Register(dburl.Scheme{Driver: "duckdb", Generator: dburl.GenOpaque, Opaque: true})
+
+	// Greenplum uses the Postgres protocol
+	dburl.Register(dburl.Scheme{Driver: "greenplum", Generator: dburl.GenPostgres, Transport: dburl.TransportUnix, Aliases: []string{"gp"}, Override: "postgres"})
+	sqlx.BindDriver("oracle", sqlx.NAMED)
+}
+
 type SqlAdapter struct {
-	DB *sqlx.DB
+	DB         *sqlx.DB
+	query      string
+	watermarks *watermarkState
+	since      string
+	// the database when different from the driver, like greenplum
+	dialect string
+	// access policy functions, detected once per connection
+	rowSecurity      bool
+	columnPrivileges bool
 }
 
 func (a *SqlAdapter) TableName() string {
+	if a.query != "" {
+		return "query"
+	}
 	return "table"
 }
 
@@ -44,11 +76,21 @@ func (a *SqlAdapter) Init(url string) error {
 	// defer db.Close()
 
 	a.DB = db
+	a.dialect = u.Unaliased
+
+	if db.DriverName() == "postgres" {
+		a.rowSecurity, a.columnPrivileges = accessPolicySupport(db)
+	}
 
 	return nil
 }
 
 func (a SqlAdapter) FetchTables() ([]table, error) {
+	// results are attributed to column names only
+	if a.query != "" {
+		return []table{{Schema: "", Name: ""}}, nil
+	}
+
 	tables := []table{}
 
 	db := a.DB
@@ -60,10 +102,21 @@ func (a SqlAdapter) FetchTables() ([]table, error) {
 		query = `SELECT '' AS table_schema, name AS table_name FROM sqlite_master WHERE type = 'table' AND name != 'sqlite_sequence' ORDER BY name`
 	case "mysql":
 		query = `SELECT table_schema AS table_schema, table_name AS table_name FROM information_schema.tables WHERE table_schema = DATABASE() OR (DATABASE() IS NULL AND table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')) ORDER BY table_schema, table_name`
-	case "sqlserver":
+	case "sqlserver", "azuresql":
 		query = `SELECT table_schema, table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE' ORDER BY table_schema, table_name`
+	case "exasol":
+		query = `SELECT table_schema AS "table_schema", table_name AS "table_name" FROM exa_all_tables WHERE table_schema = CURRENT_SCHEMA OR CURRENT_SCHEMA IS NULL ORDER BY table_schema, table_name`
+	case "duckdb":
+		query = `SELECT table_schema, table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE' ORDER BY table_schema, table_name`
+	case "oracle":
+		query = `SELECT owner AS "table_schema", table_name AS "table_name" FROM all_tables WHERE owner NOT IN (SELECT username FROM all_users WHERE oracle_maintained = 'Y') ORDER BY owner, table_name`
 	default:
-		query = `SELECT table_schema, table_name FROM information_schema.tables WHERE table_schema NOT IN ('information_schema', 'pg_catalog') ORDER BY table_schema, table_name`
+		if a.dialect == "greenplum" {
+			// skip partitions since the parent table includes their rows
+			query = `SELECT n.nspname AS table_schema, c.relname AS table_name FROM pg_class c INNER JOIN pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind IN ('r', 'p') AND n.nspname NOT IN ('information_schema', 'pg_catalog', 'gp_toolkit', 'pg_aoseg', 'pg_bitmapindex') AND n.nspname NOT LIKE 'pg_temp%' AND NOT EXISTS (SELECT 1 FROM pg_inherits i WHERE i.inhrelid = c.oid) ORDER BY 1, 2`
+		} else {
+			query = `SELECT table_schema, table_name FROM information_schema.tables WHERE table_schema NOT IN ('information_schema', 'pg_catalog') ORDER BY table_schema, table_name`
+		}
 	}
 
 	err := db.Select(&tables, query)
@@ -74,38 +127,62 @@ func (a SqlAdapter) FetchTables() ([]table, error) {
 	return tables, nil
 }
 
-func (a SqlAdapter) FetchTableData(table table, limit int) (*tableData, error) {
+func (a SqlAdapter) FetchTableData(ctx context.Context, table table, limit int) (*tableData, error) {
+	return a.FetchTableDataWithLimits(ctx, table, limit, rowLimits{})
+}
+
+func (a SqlAdapter) FetchTableDataWithLimits(ctx context.Context, table table, limit int, limits rowLimits) (*tableData, error) {
 	db := a.DB
 
+	column, start, err := a.updateWatermark(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
 	var sql string
-	if db.DriverName() == "postgres" {
-		quotedTable := quoteIdent(table.Schema) + "." + quoteIdent(table.Name)
+	var args []interface{}
+	if a.query != "" {
+		sql = a.query
+	} else if start != "" {
+		// only sample rows added or changed since the watermark
+		sql = db.Rebind(a.sinceSql(table, column, limit))
+		args = append(args, watermarkArg(start))
+	} else if a.dialect == "greenplum" {
+		sql, err = a.greenplumSampleSql(ctx, table, limit)
+		if err != nil {
+			return nil, err
+		}
+	} else if db.DriverName() == "postgres" {
+		quotedTable := a.tableRef(table)
 
-		if tsmSystemRowsSupported(db) {
+		if tsmSystemRowsSupported(ctx, db) {
 			sql = fmt.Sprintf("SELECT * FROM %s TABLESAMPLE SYSTEM_ROWS(%d)", quotedTable, limit)
 		} else {
 			// TODO randomize
 			sql = fmt.Sprintf("SELECT * FROM %s LIMIT %d", quotedTable, limit)
 		}
 	} else if db.DriverName() == "sqlite3" {
-		// TODO quote table name
 		// TODO make more efficient if primary key exists
 		// https://stackoverflow.com/questions/1253561/sqlite-order-by-rand
-		sql = fmt.Sprintf("SELECT * FROM %s ORDER BY RANDOM() LIMIT %d", table.Name, limit)
-	} else if db.DriverName() == "sqlserver" {
-		// TODO quote table name
-		sql = fmt.Sprintf("SELECT * FROM %s TABLESAMPLE (%d rows)", table.Name, limit)
+		sql = fmt.Sprintf("SELECT * FROM %s ORDER BY RANDOM() LIMIT %d", a.tableRef(table), limit)
+	} else if a.isSqlServer() {
+		sql = fmt.Sprintf("SELECT * FROM %s TABLESAMPLE (%d rows)", a.tableRef(table), limit)
+	} else if db.DriverName() == "oracle" {
+		// TODO randomize
+		sql = fmt.Sprintf("SELECT * FROM %s WHERE ROWNUM <= %d", a.tableRef(table), limit)
+	} else if db.DriverName() == "duckdb" {
+		sql = fmt.Sprintf("SELECT * FROM %s USING SAMPLE %d ROWS", a.tableRef(table), limit)
 	} else {
-		// TODO quote table name
 		// mysql
-		sql = fmt.Sprintf("SELECT * FROM %s LIMIT %d", table.Schema+"."+table.Name, limit)
+		sql = fmt.Sprintf("SELECT * FROM %s LIMIT %d", a.tableRef(table), limit)
 	}
 
 	// run query on each table
-	rows, err := db.Query(sql)
+	rows, err := db.QueryContext(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	// read everything as string and discard empty strings
 	cols, err := rows.ColumnTypes()
@@ -123,7 +200,7 @@ func (a SqlAdapter) FetchTableData(table table, limit int) (*tableData, error) {
 	}
 
 	// check values
-	rawResult := make([][]byte, len(cols))
+	rawResult := make([]rawValue, len(cols))
 
 	columnValues := make([][]string, len(cols))
 	for i := range columnValues {
@@ -135,17 +212,41 @@ func (a SqlAdapter) FetchTableData(table table, limit int) (*tableData, error) {
 		dest[i] = &rawResult[i] // Put pointers to each string in the interface slice
 	}
 
-	for rows.Next() {
+	// rows since the watermark are ordered by it, so the watermark advances to the last row sampled
+	// and rows past the sample size are sampled next time
+	watermarkIndex := -1
+	if start != "" && a.watermarks != nil {
+		watermarkIndex = indexOf(columnNames, column)
+	}
+	var lastValue string
+
+	// queries may return more rows than the sample size
+	rowCount := 0
+	exhausted := false
+	for ; rowCount < limit && rows.Next(); rowCount++ {
+		// scan the rows read so far when time runs out
+		if rowCount > 0 && !limits.deadline.IsZero() && time.Now().After(limits.deadline) {
+			exhausted = true
+			break
+		}
+
 		err = rows.Scan(dest...)
 		if err != nil {
 			return nil, err
 		}
 
+		if watermarkIndex != -1 && rawResult[watermarkIndex] != nil {
+			lastValue = string(rawResult[watermarkIndex])
+		}
+
 		for i, raw := range rawResult {
 			if raw == nil {
 				// ignore
 			} else {
 				str := string(raw)
+				if limits.columns != nil {
+					str, _ = limits.columns.add(i, str)
+				}
 				if str != "" {
 					columnValues[i] = append(columnValues[i], str)
 				}
@@ -153,17 +254,399 @@ func (a SqlAdapter) FetchTableData(table table, limit int) (*tableData, error) {
 		}
 	}
 
-	return &tableData{columnNames, columnValues}, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if lastValue != "" {
+		a.watermarks.setPending(table.displayName(), watermark{Column: column, Value: lastValue})
+	}
+
+	data := &tableData{ColumnNames: columnNames, ColumnValues: columnValues, ColumnTypes: columnTypes, RowCount: rowCount}
+	if exhausted {
+		return data, errTimeBudget
+	}
+	return data, nil
+}
+
+// reads column metadata without reading rows
+func (a SqlAdapter) FetchColumns(ctx context.Context, table table) ([]string, []string, error) {
+	var sql string
+	if a.isSqlServer() {
+		sql = fmt.Sprintf("SELECT TOP 0 * FROM %s", a.tableRef(table))
+	} else if a.DB.DriverName() == "oracle" {
+		sql = fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", a.tableRef(table))
+	} else {
+		sql = fmt.Sprintf("SELECT * FROM %s LIMIT 0", a.tableRef(table))
+	}
+
+	rows, err := a.DB.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columnNames := make([]string, len(cols))
+	columnTypes := make([]string, len(cols))
+	for i, col := range cols {
+		columnNames[i] = col.Name()
+		columnTypes[i] = col.DatabaseTypeName()
+	}
+
+	return columnNames, columnTypes, nil
+}
+
+// detects row-level security, column privileges, and dynamic data masking
+func (a SqlAdapter) FetchAccessPolicy(ctx context.Context, table table) (*accessPolicy, error) {
+	policy := &accessPolicy{}
+	if a.query != "" {
+		return policy, nil
+	}
+
+	db := a.DB
+	var err error
+
+	if db.DriverName() == "postgres" {
+		if a.rowSecurity {
+			err = db.GetContext(ctx, &policy.RowSecurity, `SELECT row_security_active(c.oid) FROM pg_class c INNER JOIN pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname = $1 AND c.relname = $2`, table.Schema, table.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if a.columnPrivileges {
+			err = db.SelectContext(ctx, &policy.DeniedColumns, `SELECT a.attname FROM pg_attribute a INNER JOIN pg_class c ON c.oid = a.attrelid INNER JOIN pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped AND NOT has_column_privilege(c.oid, a.attnum, 'SELECT') ORDER BY a.attnum`, table.Schema, table.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else if a.isSqlServer() {
+		ref := a.tableRef(table)
+
+		var filterPredicates int
+		err = db.GetContext(ctx, &filterPredicates, db.Rebind(`SELECT COUNT(*) FROM sys.security_predicates sp INNER JOIN sys.security_policies p ON p.object_id = sp.object_id WHERE p.is_enabled = 1 AND sp.predicate_type = 0 AND sp.target_object_id = OBJECT_ID(?)`), ref)
+		if err != nil {
+			return nil, err
+		}
+		policy.RowSecurity = filterPredicates > 0
+
+		err = db.SelectContext(ctx, &policy.DeniedColumns, db.Rebind(`SELECT name FROM sys.columns WHERE object_id = OBJECT_ID(?) AND HAS_PERMS_BY_NAME(?, 'OBJECT', 'SELECT', name, 'COLUMN') = 0 ORDER BY column_id`), ref, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		err = db.SelectContext(ctx, &policy.MaskedColumns, db.Rebind(`SELECT name FROM sys.masked_columns WHERE object_id = OBJECT_ID(?) AND is_masked = 1 AND HAS_PERMS_BY_NAME(NULL, NULL, 'UNMASK') = 0 ORDER BY column_id`), ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// reads comments for Postgres and MySQL
+func (a SqlAdapter) FetchColumnComments(ctx context.Context, table table) (map[string]string, error) {
+	comments := make(map[string]string)
+	if a.query != "" {
+		return comments, nil
+	}
+
+	db := a.DB
+	var rows []columnComment
+	var err error
+
+	switch db.DriverName() {
+	case "postgres":
+		err = db.SelectContext(ctx, &rows, `SELECT a.attname AS column_name, col_description(c.oid, a.attnum) AS column_comment FROM pg_attribute a INNER JOIN pg_class c ON c.oid = a.attrelid INNER JOIN pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped AND col_description(c.oid, a.attnum) IS NOT NULL`, table.Schema, table.Name)
+	case "mysql":
+		err = db.SelectContext(ctx, &rows, `SELECT column_name AS column_name, column_comment AS column_comment FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_comment != ''`, table.Schema, table.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		comments[row.Column] = row.Comment
+	}
+	return comments, nil
+}
+
+func (a SqlAdapter) IsAccessDenied(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "42501"
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1142 || mysqlErr.Number == 1143
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		return mssqlErr.Number == 229 || mssqlErr.Number == 230
+	}
+
+	// insufficient privileges
+	return strings.Contains(err.Error(), "ORA-01031")
+}
+
+// finds the watermark column and the value to sample from
+// without a previous watermark, the table is sampled as usual and the watermark starts at the current max
+func (a SqlAdapter) updateWatermark(ctx context.Context, table table) (string, string, error) {
+	if a.query != "" || (a.watermarks == nil && a.since == "") {
+		return "", "", nil
+	}
+
+	columnNames, columnTypes, err := a.FetchColumns(ctx, table)
+	if err != nil {
+		return "", "", err
+	}
+
+	column := findWatermarkColumn(columnNames, timestampWatermarkColumns)
+	// --since only applies to timestamps
+	if column == "" && a.watermarks != nil {
+		column, err = a.primaryKey(ctx, table, columnNames, columnTypes)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if column == "" {
+		return "", "", nil
+	}
+
+	var start string
+	if a.watermarks != nil {
+		previous, ok := a.watermarks.Tables[table.displayName()]
+		if ok && previous.Column == column {
+			start = previous.Value
+		}
+	}
+	if start == "" && a.since != "" && stringInSlice(strings.ToLower(column), timestampWatermarkColumns) {
+		start = a.since
+	}
+
+	if a.watermarks != nil && start == "" {
+		// read before sampling so rows written during the scan are included next time
+		var max interface{}
+		err = a.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT MAX(%s) FROM %s", a.quoteColumn(column), a.tableRef(table))).Scan(&max)
+		if err != nil {
+			return "", "", err
+		}
+		if max != nil {
+			a.watermarks.setPending(table.displayName(), watermark{Column: column, Value: watermarkValue(max)})
+		}
+	}
+
+	return column, start, nil
+}
+
+// returns the primary key if it's a single integer column, since those usually increase
+func (a SqlAdapter) primaryKey(ctx context.Context, table table, columnNames []string, columnTypes []string) (string, error) {
+	db := a.DB
+
+	var query string
+	var args []interface{}
+	switch db.DriverName() {
+	case "postgres":
+		query = `SELECT a.attname FROM pg_index i INNER JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) WHERE i.indrelid = $1::regclass AND i.indisprimary`
+		args = []interface{}{a.tableRef(table)}
+	case "mysql":
+		query = `SELECT column_name FROM information_schema.key_column_usage WHERE table_schema = ? AND table_name = ? AND constraint_name = 'PRIMARY'`
+		args = []interface{}{table.Schema, table.Name}
+	case "sqlserver", "azuresql":
+		query = db.Rebind(`SELECT kcu.column_name FROM information_schema.table_constraints tc INNER JOIN information_schema.key_column_usage kcu ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = ? AND tc.table_name = ?`)
+		args = []interface{}{table.Schema, table.Name}
+	case "oracle":
+		query = `SELECT cc.column_name FROM all_constraints c INNER JOIN all_cons_columns cc ON cc.owner = c.owner AND cc.constraint_name = c.constraint_name WHERE c.constraint_type = 'P' AND c.owner = :1 AND c.table_name = :2`
+		args = []interface{}{table.Schema, table.Name}
+	case "sqlite3":
+		query = `SELECT name FROM pragma_table_info(?) WHERE pk > 0`
+		args = []interface{}{table.Name}
+	default:
+		return "", nil
+	}
+
+	keys := []string{}
+	err := db.SelectContext(ctx, &keys, query, args...)
+	if err != nil {
+		return "", err
+	}
+	if len(keys) != 1 {
+		return "", nil
+	}
+
+	i := indexOf(columnNames, keys[0])
+	if i == -1 || !hasTypeName(columnTypes[i], integerTypes) {
+		return "", nil
+	}
+	return keys[0], nil
+}
+
+func (a SqlAdapter) sinceSql(table table, column string, limit int) string {
+	quotedColumn := a.quoteColumn(column)
+	switch a.DB.DriverName() {
+	case "sqlserver", "azuresql":
+		return fmt.Sprintf("SELECT TOP %d * FROM %s WHERE %s > ? ORDER BY %s", limit, a.tableRef(table), quotedColumn, quotedColumn)
+	case "oracle":
+		return fmt.Sprintf("SELECT * FROM (SELECT * FROM %s WHERE %s > ? ORDER BY %s) WHERE ROWNUM <= %d", a.tableRef(table), quotedColumn, quotedColumn, limit)
+	default:
+		return fmt.Sprintf("SELECT * FROM %s WHERE %s > ? ORDER BY %s LIMIT %d", a.tableRef(table), quotedColumn, quotedColumn, limit)
+	}
+}
+
+// rows are spread across segments, so take a random fraction of each
+// instead of the first rows returned, which can all come from one segment
+func (a SqlAdapter) greenplumSampleSql(ctx context.Context, table table, limit int) (string, error) {
+	quotedTable := a.tableRef(table)
+
+	var estimate float64
+	err := a.DB.QueryRowContext(ctx, "SELECT reltuples FROM pg_class WHERE oid = $1::regclass", quotedTable).Scan(&estimate)
+	if err != nil {
+		return "", err
+	}
+
+	if estimate > float64(limit) {
+		// oversample since the estimate may be stale
+		fraction := 2 * float64(limit) / estimate
+		return fmt.Sprintf("SELECT * FROM %s WHERE random() < %g LIMIT %d", quotedTable, fraction, limit), nil
+	}
+	return fmt.Sprintf("SELECT * FROM %s LIMIT %d", quotedTable, limit), nil
 }
 
 // helpers
 
+func (a SqlAdapter) quoteColumn(column string) string {
+	switch a.DB.DriverName() {
+	case "mysql":
+		return "`" + strings.Replace(column, "`", "``", -1) + "`"
+	case "sqlserver", "azuresql":
+		return quoteBracket(column)
+	default:
+		return quoteIdent(column)
+	}
+}
+
+func (a SqlAdapter) tableRef(table table) string {
+	switch a.DB.DriverName() {
+	case "postgres", "oracle", "exasol", "duckdb":
+		return quoteIdent(table.Schema) + "." + quoteIdent(table.Name)
+	case "sqlserver", "azuresql":
+		return quoteBracket(table.Schema) + "." + quoteBracket(table.Name)
+	case "sqlite3":
+		// TODO quote table name
+		return table.Name
+	default:
+		// TODO quote table name
+		return table.Schema + "." + table.Name
+	}
+}
+
+func (a SqlAdapter) isSqlServer() bool {
+	driver := a.DB.DriverName()
+	return driver == "sqlserver" || driver == "azuresql"
+}
+
+// uses the default Azure credential chain (environment, managed identity, Azure CLI)
+// when no user or other authentication method is specified
+func genAzureSql(u *dburl.URL) (string, error) {
+	v := *u
+	q := v.Query()
+	if q.Get("fedauth") == "" && v.User == nil {
+		q.Set("fedauth", "ActiveDirectoryDefault")
+		v.RawQuery = q.Encode()
+	}
+	return dburl.GenSqlserver(&v)
+}
+
 func quoteIdent(column string) string {
 	return pq.QuoteIdentifier(column)
 }
 
-func tsmSystemRowsSupported(db *sqlx.DB) bool {
-	row := db.QueryRow("SELECT COUNT(*) FROM pg_extension WHERE extname = 'tsm_system_rows'")
+// rawValue reads any column as bytes, including nested types like lists and structs
+type rawValue []byte
+
+func (v *rawValue) Scan(src interface{}) error {
+	switch typedVal := src.(type) {
+	case nil:
+		*v = nil
+	case []byte:
+		*v = append(rawValue{}, typedVal...)
+	case string:
+		*v = rawValue(typedVal)
+	case time.Time:
+		*v = rawValue(typedVal.Format(time.RFC3339Nano))
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		*v = rawValue(fmt.Sprint(typedVal))
+	default:
+		data, err := json.Marshal(typedVal)
+		if err != nil {
+			data = []byte(fmt.Sprint(typedVal))
+		}
+		*v = data
+	}
+	return nil
+}
+
+func quoteBracket(ident string) string {
+	return "[" + strings.Replace(ident, "]", "]]", -1) + "]"
+}
+
+type tableRows struct {
+	table
+	Rows int64 `db:"table_rows"`
+}
+
+// estimates from the catalog, so tables do not need to be counted
+func (a SqlAdapter) EstimateRows() map[string]int64 {
+	if a.query != "" {
+		return nil
+	}
+
+	var query string
+	switch a.DB.DriverName() {
+	case "postgres":
+		// -1 for tables that have not been analyzed
+		query = `SELECT n.nspname AS table_schema, c.relname AS table_name, c.reltuples::bigint AS table_rows FROM pg_class c INNER JOIN pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind IN ('r', 'p')`
+	case "mysql":
+		query = `SELECT table_schema AS table_schema, table_name AS table_name, COALESCE(table_rows, -1) AS table_rows FROM information_schema.tables`
+	default:
+		return nil
+	}
+
+	var rows []tableRows
+	err := a.DB.Select(&rows, query)
+	if err != nil {
+		return nil
+	}
+
+	estimates := make(map[string]int64)
+	for _, row := range rows {
+		if row.Rows >= 0 {
+			estimates[row.table.displayName()] = row.Rows
+		}
+	}
+	return estimates
+}
+
+// databases that use the Postgres protocol may not have the functions for access policies,
+// like CockroachDB, Redshift, and Greenplum 6 and earlier
+func accessPolicySupport(db *sqlx.DB) (bool, bool) {
+	var names []string
+	err := db.Select(&names, `SELECT DISTINCT proname FROM pg_proc WHERE proname IN ('row_security_active', 'has_column_privilege')`)
+	if err != nil {
+		return false, false
+	}
+	return stringInSlice("row_security_active", names), stringInSlice("has_column_privilege", names)
+}
+
+func tsmSystemRowsSupported(ctx context.Context, db *sqlx.DB) bool {
+	row := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pg_extension WHERE extname = 'tsm_system_rows'")
 	var count int
 	err := row.Scan(&count)
 	if err != nil {
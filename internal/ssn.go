@@ -0,0 +1,18 @@
+package internal
+
+// SSNs are never issued with area 000, 666, or 900-999, group 00, or serial 0000
+// https://www.ssa.gov/employer/randomization.html
+func validSsn(v string) bool {
+	digits := make([]byte, 0, 9)
+	for i := 0; i < len(v); i++ {
+		if v[i] >= '0' && v[i] <= '9' {
+			digits = append(digits, v[i])
+		}
+	}
+	if len(digits) != 9 {
+		return false
+	}
+
+	area, group, serial := string(digits[:3]), string(digits[3:5]), string(digits[5:])
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
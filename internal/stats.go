@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stats summarize a scan for trending, separate from findings
+type scanStats struct {
+	Source          string            `json:"source"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	GeneratedAt     time.Time         `json:"generated_at"`
+	DurationSeconds float64           `json:"duration_seconds"`
+	ObjectsScanned  int               `json:"objects_scanned"`
+	ObjectsSkipped  int               `json:"objects_skipped"`
+	RowsSampled     int               `json:"rows_sampled"`
+	MatchesCount    int               `json:"matches_count"`
+	Matches         []ruleMatchStats  `json:"matches"`
+	// unique card numbers for each brand, summed across matches
+	CardBrands map[string]int `json:"card_brands,omitempty"`
+}
+
+type ruleMatchStats struct {
+	Rule       string `json:"rule"`
+	Confidence string `json:"confidence"`
+	Count      int    `json:"count"`
+}
+
+func newScanStats(source string, labels map[string]string, matchList []ruleMatch, scanCoverage *coverage, duration time.Duration) scanStats {
+	counts := make(map[ruleMatchStats]int)
+	var brands map[string]int
+	for _, match := range matchList {
+		counts[ruleMatchStats{Rule: match.RuleName, Confidence: match.Confidence}]++
+		for brand, count := range match.Brands {
+			if brands == nil {
+				brands = make(map[string]int)
+			}
+			brands[brand] += count
+		}
+	}
+
+	matches := []ruleMatchStats{}
+	for key, count := range counts {
+		key.Count = count
+		matches = append(matches, key)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Rule != matches[j].Rule {
+			return matches[i].Rule < matches[j].Rule
+		}
+		return matches[i].Confidence < matches[j].Confidence
+	})
+
+	return scanStats{
+		Source:          redactUrl(source),
+		Labels:          labels,
+		GeneratedAt:     time.Now().UTC(),
+		DurationSeconds: duration.Seconds(),
+		ObjectsScanned:  len(scanCoverage.Scanned()),
+		ObjectsSkipped:  len(scanCoverage.Skipped()),
+		RowsSampled:     scanCoverage.Rows(),
+		MatchesCount:    len(matchList),
+		Matches:         matches,
+		CardBrands:      brands,
+	}
+}
+
+// JSON for .json files, otherwise the Prometheus text format for the node exporter textfile collector
+func (s scanStats) save(filename string) error {
+	var data []byte
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		var err error
+		data, err = json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = s.prometheus()
+	}
+	return writeFileAtomic(filename, data)
+}
+
+func (s scanStats) prometheus() []byte {
+	var buf bytes.Buffer
+	source := "source=" + prometheusLabel(s.Source)
+
+	// scan labels are added to every metric
+	keys := make([]string, 0, len(s.Labels))
+	for key := range s.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		source += "," + key + "=" + prometheusLabel(s.Labels[key])
+	}
+
+	metric := func(name string, help string, value string) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		fmt.Fprintf(&buf, "%s{%s} %s\n", name, source, value)
+	}
+
+	metric("pdscan_last_run_timestamp_seconds", "Time the scan finished", strconv.FormatInt(s.GeneratedAt.Unix(), 10))
+	metric("pdscan_scan_duration_seconds", "Duration of the scan", strconv.FormatFloat(s.DurationSeconds, 'f', 3, 64))
+	metric("pdscan_objects_scanned", "Tables, files, and other objects scanned", strconv.Itoa(s.ObjectsScanned))
+	metric("pdscan_objects_skipped", "Tables, files, and other objects not scanned", strconv.Itoa(s.ObjectsSkipped))
+	metric("pdscan_rows_sampled", "Rows, documents, keys, or lines sampled", strconv.Itoa(s.RowsSampled))
+
+	fmt.Fprintln(&buf, "# HELP pdscan_matches Columns, fields, or files with sensitive data")
+	fmt.Fprintln(&buf, "# TYPE pdscan_matches gauge")
+	for _, match := range s.Matches {
+		fmt.Fprintf(&buf, "pdscan_matches{%s,rule=%s,confidence=%s} %d\n", source, prometheusLabel(match.Rule), prometheusLabel(match.Confidence), match.Count)
+	}
+
+	if len(s.CardBrands) > 0 {
+		fmt.Fprintln(&buf, "# HELP pdscan_card_numbers Unique card numbers found by brand")
+		fmt.Fprintln(&buf, "# TYPE pdscan_card_numbers gauge")
+		for _, brand := range cardBrands {
+			if count, ok := s.CardBrands[brand.Name]; ok {
+				fmt.Fprintf(&buf, "pdscan_card_numbers{%s,brand=%s} %d\n", source, prometheusLabel(brand.Name), count)
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func prometheusLabel(value string) string {
+	return `"` + prometheusEscaper.Replace(value) + `"`
+}
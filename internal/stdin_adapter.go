@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"os"
+	"time"
+)
+
+// StdinAdapter scans piped input, like pg_dump | pdscan -
+type StdinAdapter struct{}
+
+func (a *StdinAdapter) ObjectName() string {
+	return "input"
+}
+
+func (a *StdinAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanFiles(a, scanOpts)
+}
+
+func (a *StdinAdapter) Init(url string) error {
+	return nil
+}
+
+func (a StdinAdapter) FetchFiles() ([]string, error) {
+	return []string{"stdin"}, nil
+}
+
+// the size is not known until it is read
+func (a StdinAdapter) FileSize(filename string) (int64, error) {
+	return 0, nil
+}
+
+func (a StdinAdapter) FindFileMatches(filename string, matchFinder *MatchFinder) error {
+	if setReadDeadline(matchFinder.ctx, os.Stdin) {
+		defer os.Stdin.SetReadDeadline(time.Time{})
+	} else if matchFinder.ctx.Done() != nil {
+		return processFile(contextReader{matchFinder.ctx, os.Stdin}, matchFinder)
+	}
+
+	return processFile(os.Stdin, matchFinder)
+}
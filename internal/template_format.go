@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// TemplateFormatter renders all matches with a Go template after the scan.
+type TemplateFormatter struct {
+	template *template.Template
+	source   string
+	matches  []reportMatch
+	coverage *coverage
+	labels   map[string]string
+	mutex    sync.Mutex
+}
+
+// reportData is the model passed to templates
+type reportData struct {
+	Source      string
+	GeneratedAt time.Time
+	Matches     []reportMatch
+	Scanned     []string
+	Skipped     []skippedObject
+	Labels      map[string]string
+}
+
+type reportMatch struct {
+	Source      string
+	Identifier  string
+	Rule        string
+	DisplayName string
+	MatchType   string
+	Confidence  string
+	Count       int
+	CountName   string
+	Values      []string
+	Brands      map[string]int
+	Truncated   bool
+	Account     string
+	Labels      map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	"join":      strings.Join,
+	"pluralize": pluralize,
+	"upper":     strings.ToUpper,
+}
+
+func (f *TemplateFormatter) Load(filename string, source string) error {
+	if filename == "" {
+		return fmt.Errorf("--template is required with --format template")
+	}
+
+	tmpl, err := template.New(filepath.Base(filename)).Funcs(templateFuncs).ParseFiles(filename)
+	if err != nil {
+		return err
+	}
+
+	f.template = tmpl
+	f.source = redactUrl(source)
+	return nil
+}
+
+func (f *TemplateFormatter) setCoverage(source string, c *coverage) {
+	f.coverage = c
+}
+
+func (f *TemplateFormatter) setLabels(labels map[string]string) {
+	f.labels = labels
+}
+
+func (f *TemplateFormatter) PrintMatch(writer io.Writer, match matchInfo) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	reportMatch := newReportMatch(match)
+	reportMatch.Labels = f.labels
+	f.matches = append(f.matches, reportMatch)
+	return nil
+}
+
+func (f *TemplateFormatter) Finish(writer io.Writer) error {
+	// matches arrive in parallel
+	sort.SliceStable(f.matches, func(i, j int) bool {
+		return f.matches[i].Identifier < f.matches[j].Identifier
+	})
+
+	return f.template.Execute(writer, reportData{
+		Source:      f.source,
+		GeneratedAt: time.Now().UTC(),
+		Matches:     f.matches,
+		Scanned:     f.coverage.Scanned(),
+		Skipped:     f.coverage.Skipped(),
+		Labels:      f.labels,
+	})
+}
+
+func newReportMatch(match matchInfo) reportMatch {
+	return reportMatch{
+		Source:      match.Source,
+		Identifier:  match.Identifier,
+		Rule:        match.RuleName,
+		DisplayName: match.DisplayName,
+		MatchType:   match.MatchType,
+		Confidence:  match.Confidence,
+		Count:       match.LineCount,
+		CountName:   match.RowStr,
+		Values:      match.Values,
+		Brands:      match.Brands,
+		Truncated:   match.Truncated,
+		Account:     match.Account,
+	}
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errTimeBudget is returned when a file or table runs out of time partway through
+var errTimeBudget = errors.New("time budget exhausted")
+
+// timeBudget spreads the scan time across the tables or files left in proportion to their size
+// so time unused by small ones goes to the rest
+type timeBudget struct {
+	deadline  time.Time
+	remaining int64
+	workers   int
+	mutex     sync.Mutex
+}
+
+// sizes are relative, like bytes for files or sampled rows for tables
+func newTimeBudget(duration time.Duration, sizes []int64, workers int) *timeBudget {
+	var total int64
+	for _, size := range sizes {
+		total += size
+	}
+	return &timeBudget{deadline: time.Now().Add(duration), remaining: total, workers: workers}
+}
+
+// returns the deadline for the next table or file, or false if no time is left
+func (b *timeBudget) next(size int64) (time.Time, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	left := b.deadline.Sub(now)
+	if left <= 0 {
+		return time.Time{}, false
+	}
+
+	// workers scan objects in parallel, so each gets its part of the time left
+	share := left
+	if part := size * int64(b.workers); b.remaining > part {
+		share = time.Duration(float64(left) * float64(part) / float64(b.remaining))
+	}
+	b.remaining -= size
+	return now.Add(share), true
+}
+
+// sizes of objects without a known size count the same as the average
+func budgetSizes(sizes []int64) []int64 {
+	var total int64
+	known := 0
+	for _, size := range sizes {
+		if size > 0 {
+			total += size
+			known++
+		}
+	}
+
+	average := int64(1)
+	if known > 0 {
+		average = total / int64(known)
+		if average < 1 {
+			average = 1
+		}
+	}
+
+	weights := make([]int64, len(sizes))
+	for i, size := range sizes {
+		if size > 0 {
+			weights[i] = size
+		} else {
+			weights[i] = average
+		}
+	}
+	return weights
+}
+
+// listedSizeAdapter is implemented by file adapters that get sizes when listing files,
+// so the time budget can be split by size without a request for each file
+type listedSizeAdapter interface {
+	listedSize(file string) int64
+}
+
+// bytes of each file, or 0 if unknown
+func listedSizes(adapter FileAdapter, files []string) []int64 {
+	sizes := make([]int64, len(files))
+	if sizeAdapter, ok := adapter.(listedSizeAdapter); ok {
+		for i, file := range files {
+			sizes[i] = sizeAdapter.listedSize(file)
+		}
+	}
+	return sizes
+}
+
+// rows read from each table, which is at most the sample size
+func sampleSizes(adapter DataStoreAdapter, tables []table, limit int) map[string]int64 {
+	var estimates map[string]int64
+	if sizeAdapter, ok := adapter.(tableSizeAdapter); ok {
+		estimates = sizeAdapter.EstimateRows()
+	}
+
+	sizes := make([]int64, len(tables))
+	for i, table := range tables {
+		if rows, ok := estimates[table.displayName()]; ok {
+			if rows > int64(limit) {
+				rows = int64(limit)
+			}
+			if rows < 1 {
+				rows = 1
+			}
+			sizes[i] = rows
+		}
+	}
+
+	tableSizes := make(map[string]int64)
+	for i, size := range budgetSizes(sizes) {
+		tableSizes[tables[i].displayName()] = size
+	}
+	return tableSizes
+}
+
+// checked for each line or chunk, so a single long line can't run past the deadline
+func (a *MatchFinder) expired() bool {
+	if a.ctx.Err() != nil {
+		return true
+	}
+	return !a.deadline.IsZero() && time.Now().After(a.deadline)
+}
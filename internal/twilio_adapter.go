@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"errors"
+	"net/url"
+	"os"
+)
+
+// TwilioAdapter scans bodies of recent SMS messages with the Twilio API
+type TwilioAdapter struct {
+	RestAdapter
+}
+
+func (a *TwilioAdapter) Scan(scanOpts ScanOpts) ([]ruleMatch, error) {
+	return scanDataStore(a, scanOpts)
+}
+
+func (a *TwilioAdapter) Init(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	accountSid := u.User.Username()
+	if accountSid == "" {
+		accountSid = os.Getenv("TWILIO_ACCOUNT_SID")
+	}
+	authToken, ok := u.User.Password()
+	if !ok {
+		authToken = os.Getenv("TWILIO_AUTH_TOKEN")
+	}
+	if accountSid == "" || authToken == "" {
+		return errors.New("no account SID or auth token specified")
+	}
+
+	return a.init(newTwilioConfig("https://api.twilio.com", accountSid, authToken))
+}
+
+// messages are listed newest first, and next_page_uri is relative to the API
+func newTwilioConfig(endpoint string, accountSid string, authToken string) *restConfig {
+	config := &restConfig{Endpoint: endpoint}
+	config.Auth.Type = "basic"
+	config.Auth.Username = accountSid
+	config.Auth.Password = authToken
+	config.Resources = []restResource{
+		{Name: "messages", Path: "/2010-04-01/Accounts/" + url.PathEscape(accountSid) + "/Messages.json", Items: "messages", Fields: []string{"body"}, Pagination: restPagination{Type: "next", SizeParam: "PageSize", Size: 1000, Next: "next_page_uri"}},
+	}
+	return config
+}
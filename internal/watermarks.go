@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watermarks record how far each table has been scanned
+// so later scans only sample new and changed rows
+type watermarkState struct {
+	Tables map[string]watermark `json:"tables"`
+	// a scan that has not finished, so partial results are kept if it is interrupted
+	Scan *scanProgress `json:"scan,omitempty"`
+
+	filename string
+	// new watermarks for tables being scanned
+	pending map[string]watermark
+	mutex   sync.Mutex
+}
+
+type watermark struct {
+	Column string `json:"column"`
+	Value  string `json:"value"`
+}
+
+// columns used for watermarks, in order of preference, before the primary key
+var timestampWatermarkColumns = []string{"updated_at", "modified_at", "created_at"}
+
+func loadWatermarks(filename string) (*watermarkState, error) {
+	state := &watermarkState{Tables: make(map[string]watermark), filename: filename, pending: make(map[string]watermark)}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid state file %s: %s", filename, err)
+	}
+	if state.Tables == nil {
+		state.Tables = make(map[string]watermark)
+	}
+	return state, nil
+}
+
+func (s *watermarkState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.filename, append(data, '\n'))
+}
+
+// watermarks are only committed for tables that finish scanning
+func (s *watermarkState) setPending(name string, w watermark) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pending[name] = w
+}
+
+// returns an empty string if no column is suitable
+func findWatermarkColumn(columnNames []string, candidates []string) string {
+	for _, candidate := range candidates {
+		for _, col := range columnNames {
+			if strings.ToLower(col) == candidate {
+				return col
+			}
+		}
+	}
+	return ""
+}
+
+func watermarkValue(value interface{}) string {
+	switch typedVal := value.(type) {
+	case time.Time:
+		return typedVal.Format(time.RFC3339Nano)
+	case []byte:
+		return string(typedVal)
+	default:
+		return fmt.Sprint(typedVal)
+	}
+}
+
+// times and integers are passed with their type so drivers compare them natively
+func watermarkArg(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	t, err := parseTime(value)
+	if err == nil {
+		return t
+	}
+	return value
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid time: %s", value)
+}
@@ -0,0 +1,62 @@
+// Package pipeline lets programs that embed pdscan hook into matching.
+//
+// Matches go through stages: normalize, candidate match, validate, score, suppress, and emit.
+// Candidate matches come from rules and detectors, and hooks run at the other stages.
+//
+// Built-in behavior runs at these stages too. Column comment hints, like pdscan:ignore, are part
+// of candidate match, so hooks see the matches they add and not the ones they drop. The ignore file
+// runs after suppress hooks, and emit hooks get redacted values with --redact.
+//
+//	func main() {
+//		pipeline.Register(pipeline.Hooks{
+//			Name: "test-cards",
+//			Suppress: func(match pipeline.Match) bool {
+//				return match.Rule == "credit_card" && strings.HasPrefix(match.Identifier, "fixtures.")
+//			},
+//		})
+//		cmd.Execute()
+//	}
+package pipeline
+
+// Match is a rule that matched a column or file
+type Match struct {
+	Rule string
+	// critical, high, medium, or low
+	Confidence string
+	// table or file
+	Source     string
+	Identifier string
+	// value, name, format, or comment
+	Type string
+	// matched values, or the values of the column for name matches
+	Values    []string
+	LineCount int
+}
+
+// Hooks run at stages of the pipeline, and any can be nil
+type Hooks struct {
+	Name string
+	// Normalize changes values before rules are checked, like decoding
+	Normalize func(value string) string
+	// Validate reports if a value matched by a rule is real
+	// matches without valid values are dropped
+	Validate func(rule string, value string) bool
+	// Score returns the confidence of a match
+	Score func(match Match) string
+	// Suppress reports if a match should be dropped
+	Suppress func(match Match) bool
+	// Emit is called with each match that is reported, like for alerts
+	Emit func(match Match)
+}
+
+var hooks []Hooks
+
+// Register adds hooks, which run in the order they are registered
+func Register(h Hooks) {
+	hooks = append(hooks, h)
+}
+
+// Registered returns the registered hooks
+func Registered() []Hooks {
+	return hooks
+}